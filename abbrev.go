@@ -0,0 +1,57 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+	"strings"
+)
+
+// OptionsWithNames is an interface that adds the OptionNames method to
+// Options.
+//
+// OptionNames returns the names of the options, including dashes. It is used
+// to resolve the abbreviations of long options in the Abbreviations mode of
+// Parser, and is called at most once per parse.
+type OptionsWithNames interface {
+	Options
+
+	OptionNames() []string
+}
+
+// abbreviation returns the long option name abbreviates, or "" if there is
+// none. It fails if name is ambiguous.
+func (t *Tokenizer) abbreviation(name string) (string, error) {
+	if len(name) <= 2 {
+		return "", nil
+	}
+	if t.names == nil {
+		opts := t.opts
+		if k, ok := opts.(kindTracer); ok {
+			opts = k.Options
+		}
+		nopts, ok := opts.(OptionsWithNames)
+		if !ok {
+			return "", nil
+		}
+		t.names = nopts.OptionNames()
+		if t.names == nil {
+			t.names = []string{}
+		}
+	}
+	var candidates []string
+	for _, candidate := range t.names {
+		if strings.HasPrefix(candidate, name) && strings.HasPrefix(candidate, "--") && !slices.Contains(candidates, candidate) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", nil
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", errorf(CodeAmbiguousOption, name, "ambiguous option %s; could be %s", name, orList(candidates))
+	}
+}
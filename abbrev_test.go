@@ -0,0 +1,67 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+type namedOptions struct {
+	TestOptions
+}
+
+func (opts *namedOptions) OptionNames() []string {
+	return []string{"-a", "-r", "--boolean", "--required", "--optional", "--number"}
+}
+
+func TestAbbreviations(t *testing.T) {
+	p := &Parser{Abbreviations: true}
+	opts := &namedOptions{}
+	args, err := p.Parse(opts, []string{"--bool", "--req", "x", "--n=1", "--optional", "y"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"y"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "--boolean"},
+		{Name: "--required", Value: "x", HasValue: true},
+		{Name: "--number", Value: "1", HasValue: true},
+		{Name: "--optional"},
+	})
+
+	if _, err := p.Parse(&namedOptions{}, []string{"--x"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+	if _, err := p.Parse(&TestOptions{}, []string{"--bool"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s without OptionNames, but got %v", CodeUnknownOption, err)
+	}
+	if _, err := new(Parser).Parse(&namedOptions{}, []string{"--bool"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s without Abbreviations, but got %v", CodeUnknownOption, err)
+	}
+}
+
+func TestSpecAbbreviations(t *testing.T) {
+	spec := &Spec{
+		Name: "example",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}},
+			{Names: []string{"--version"}},
+			{Names: []string{"--output"}, Kind: Required},
+		},
+	}
+	p := &Parser{Abbreviations: true}
+	if _, err := p.Parse(spec, []string{"--verb", "--out", "x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if n := spec.Options[0].Count(); n != 1 {
+		t.Errorf("--verbose: Count() = %d, want 1", n)
+	}
+	if value, _ := spec.Options[2].Value(); value != "x" {
+		t.Errorf("--output = %q, want %q", value, "x")
+	}
+	_, err := p.Parse(spec, []string{"--ver"})
+	if Code(err) != CodeAmbiguousOption || err.Error() != "ambiguous option --ver; could be '--verbose' or '--version'" {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	}
+}
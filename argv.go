@@ -0,0 +1,49 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// ParseArgv is like Parse, but takes the full argument list, such as os.Args,
+// whose first element is the command name. The base name of the command is
+// set as the Prog of the returned *Error, so that it formats as
+// "prog: unknown option ..." like the messages of the standard commands.
+func ParseArgv(opts Options, argv []string) ([]string, error) {
+	return new(Parser).ParseArgv(opts, argv)
+}
+
+// ParseArgv is like the package-level ParseArgv, but reuses the buffers of p.
+func (p *Parser) ParseArgv(opts Options, argv []string) ([]string, error) {
+	if len(argv) == 0 {
+		return p.Parse(opts, nil)
+	}
+	args, err := p.Parse(opts, argv[1:])
+	if err != nil {
+		return nil, withProg(err, filepath.Base(argv[0]))
+	}
+	return args, nil
+}
+
+// withProg returns err with Prog set to prog, copying the *Error so that
+// shared errors such as ErrUnknown are not modified. The errors joined by
+// CollectErrors are set individually.
+func withProg(err error, prog string) error {
+	switch e := err.(type) {
+	case *Error:
+		c := *e
+		c.Prog = prog
+		return &c
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		wrapped := make([]error, len(errs))
+		for i, err := range errs {
+			wrapped[i] = withProg(err, prog)
+		}
+		return errors.Join(wrapped...)
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseArgv(t *testing.T) {
+	opts := &TestOptions{}
+	args, err := ParseArgv(opts, []string{"/usr/bin/prog", "-a", "x"})
+	if err != nil {
+		t.Fatalf("ParseArgv(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}})
+
+	_, err = ParseArgv(&TestOptions{}, []string{"/usr/bin/prog", "--unknown"})
+	if err == nil || err.Error() != `prog: unknown option "--unknown"` || Code(err) != CodeUnknownOption {
+		t.Errorf("ParseArgv(): unexpected error: %v", err)
+	}
+	_, err = ParseArgv(&TestOptions{}, []string{"prog", "--help"})
+	if !errors.Is(err, ErrHelp) || err.Error() != "prog: option --help: help requested" {
+		t.Errorf("ParseArgv(): unexpected error: %v", err)
+	}
+	if ErrHelp.(*Error).Prog != "" {
+		t.Errorf("ParseArgv(): ErrHelp must not be modified")
+	}
+
+	p := &Parser{CollectErrors: true}
+	_, err = p.ParseArgv(&TestOptions{}, []string{"prog", "--x", "--y"})
+	if err == nil || err.Error() != "prog: unknown option \"--x\"\nprog: unknown option \"--y\"" {
+		t.Errorf("ParseArgv(): unexpected error: %v", err)
+	}
+
+	if args, err := ParseArgv(&TestOptions{}, nil); err != nil || len(args) != 0 {
+		t.Errorf("ParseArgv(nil) = %v, %v", args, err)
+	}
+}
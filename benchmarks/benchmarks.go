@@ -0,0 +1,186 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Package benchmarks provides representative parsing workloads and helpers
+// for benchmarking Options implementations and catching allocation
+// regressions.
+//
+// The workloads use the following options, which the Options passed to Run
+// and Allocs must accept:
+//
+//	-a, -b, -c, -d, --verbose  Boolean
+//	-o, --output               Required
+//	--level                    Optional
+//	-D                         TakeTwoArgs
+//
+// Subcommands in the DeepSubcommands workload are positional arguments
+// starting with "cmd".
+package benchmarks
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+// Workload is a representative command line and the way it is parsed.
+type Workload struct {
+	// Name is the name of the workload, used as the sub-benchmark name.
+	Name string
+
+	// Args is the argument list, not including the command name.
+	Args []string
+
+	// Parse parses Args. If nil, options.Parse is used.
+	Parse func(opts options.Options, args []string) ([]string, error)
+}
+
+func (w Workload) parse(opts options.Options) error {
+	parse := w.Parse
+	if parse == nil {
+		parse = options.Parse
+	}
+	_, err := parse(opts, w.Args)
+	return err
+}
+
+func repeat(n int, args ...string) []string {
+	out := make([]string, 0, n*len(args))
+	for range n {
+		out = append(out, args...)
+	}
+	return out
+}
+
+// BundledShorts is a workload of bundled short options with attached values.
+var BundledShorts = Workload{
+	Name: "BundledShorts",
+	Args: repeat(100, "-abcd", "-abco/dev/null", "-DKEY", "VALUE"),
+}
+
+// LongWithValues is a workload of long options with separate and attached
+// values.
+var LongWithValues = Workload{
+	Name: "LongWithValues",
+	Args: repeat(100, "--verbose", "--output", "out.txt", "--output=out.txt", "--level", "--level=3"),
+}
+
+// HugePositional is a workload of ten thousand positional arguments with
+// options interleaved and a -- near the end.
+var HugePositional = Workload{
+	Name: "HugePositional",
+	Args: func() []string {
+		args := make([]string, 0, 10003)
+		for i := range 10000 {
+			if i%100 == 0 {
+				args = append(args, "-a")
+			} else {
+				args = append(args, "file"+strconv.Itoa(i))
+			}
+		}
+		return append(args, "--", "-b", "last")
+	}(),
+}
+
+// DeepSubcommands is a workload of ten nested subcommands, each followed by
+// options, parsed with options.ParseS at each level.
+var DeepSubcommands = Workload{
+	Name: "DeepSubcommands",
+	Args: func() []string {
+		var args []string
+		for i := range 10 {
+			args = append(args, "cmd"+strconv.Itoa(i), "-ab", "--output", "x")
+		}
+		return append(args, "arg")
+	}(),
+	Parse: func(opts options.Options, args []string) ([]string, error) {
+		for {
+			rest, err := options.ParseS(opts, args)
+			if errors.Is(err, options.ErrNoSubcommand) {
+				return nil, nil
+			} else if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(rest[0], "cmd") {
+				return rest, nil
+			}
+			args = rest[1:]
+		}
+	},
+}
+
+// Workloads returns all the workloads defined in this package.
+func Workloads() []Workload {
+	return []Workload{BundledShorts, LongWithValues, HugePositional, DeepSubcommands}
+}
+
+// Run runs each workload as a sub-benchmark of b, reporting allocations.
+// newOpts is called before each parse.
+func Run(b *testing.B, newOpts func() options.Options) {
+	for _, w := range Workloads() {
+		b.Run(w.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if err := w.parse(newOpts()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Allocs returns the average number of allocations made by parsing each
+// workload, keyed by the workload name. The allocations made by newOpts are
+// included. Tests can compare the results against a budget.
+func Allocs(newOpts func() options.Options) (map[string]float64, error) {
+	result := make(map[string]float64)
+	for _, w := range Workloads() {
+		var err error
+		result[w.Name] = testing.AllocsPerRun(10, func() {
+			if perr := w.parse(newOpts()); perr != nil {
+				err = perr
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Reference is an Options accepting the options of the workloads. It only
+// counts the calls, so it serves as the baseline for the parser itself.
+type Reference struct {
+	Calls int
+}
+
+// Kind implements options.Options.
+func (r *Reference) Kind(name string) options.Kind {
+	switch name {
+	case "-a", "-b", "-c", "-d", "--verbose":
+		return options.Boolean
+	case "-o", "--output":
+		return options.Required
+	case "--level":
+		return options.Optional
+	case "-D":
+		return options.TakeTwoArgs
+	default:
+		return options.Unknown
+	}
+}
+
+// Option implements options.Options.
+func (r *Reference) Option(name, value string, hasValue bool) error {
+	r.Calls++
+	return nil
+}
+
+// OptionN implements options.OptionsWithOptionN.
+func (r *Reference) OptionN(name string, values []string) error {
+	r.Calls++
+	return nil
+}
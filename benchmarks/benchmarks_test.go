@@ -0,0 +1,38 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package benchmarks
+
+import (
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+var ref = &Reference{}
+
+func newReference() options.Options {
+	return ref
+}
+
+func TestAllocs(t *testing.T) {
+	budget := map[string]float64{
+		"BundledShorts":   100,
+		"LongWithValues":  0,
+		"HugePositional":  1,
+		"DeepSubcommands": 11,
+	}
+	allocs, err := Allocs(newReference)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, limit := range budget {
+		if allocs[name] > limit {
+			t.Errorf("%s: %v allocations per run, budget is %v", name, allocs[name], limit)
+		}
+	}
+}
+
+func BenchmarkReference(b *testing.B) {
+	Run(b, newReference)
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Package bind builds an options.Spec from a struct by reflection, storing
+// the parsed values to the fields.
+package bind
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cions/go-options"
+)
+
+// A Dialect interprets the struct tags of a field.
+type Dialect interface {
+	// Option returns the option described by the field, or nil if the field
+	// is not an option. Names must be set; Kind may be left Unknown to be
+	// inferred from the field type.
+	Option(field reflect.StructField) (*Field, error)
+}
+
+// Field is the result of interpreting the struct tags of a field.
+type Field struct {
+	options.OptionSpec
+
+	// Counter indicates that an integer field counts the occurrences.
+	Counter bool
+}
+
+// Native is the native dialect, which uses the following tags:
+//
+//	option:"-v,--verbose"  names of the option (required)
+//	help:"..."             help message
+//	metavar:"FILE"         name of the value in help messages
+//	default:"..."          default value
+//	env:"FOO,BAR"          environment variables
+//	choices:"a,b,c"        permitted values
+//	required:"true"        the option must be specified
+//	max:"1"                maximum number of occurrences
+//	hidden:"true"          hide from help and completion
+//	counter:"true"         an integer field counts the occurrences
+var Native Dialect = nativeDialect{}
+
+type nativeDialect struct{}
+
+func (nativeDialect) Option(field reflect.StructField) (*Field, error) {
+	names, ok := field.Tag.Lookup("option")
+	if !ok || names == "-" {
+		return nil, nil
+	}
+	f := &Field{}
+	f.Names = splitList(names)
+	f.Help = field.Tag.Get("help")
+	f.Metavar = field.Tag.Get("metavar")
+	f.Default = field.Tag.Get("default")
+	f.Env = splitList(field.Tag.Get("env"))
+	f.Choices = splitList(field.Tag.Get("choices"))
+	var err error
+	if f.Required, err = boolTag(field, "required"); err != nil {
+		return nil, err
+	}
+	if value, ok := field.Tag.Lookup("max"); ok {
+		if f.Max, err = strconv.Atoi(value); err != nil || f.Max < 0 {
+			return nil, fmt.Errorf("bind: field %s: invalid max tag: %q", field.Name, value)
+		}
+	}
+	if f.Hidden, err = boolTag(field, "hidden"); err != nil {
+		return nil, err
+	}
+	if f.Counter, err = boolTag(field, "counter"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func boolTag(field reflect.StructField, key string) (bool, error) {
+	value, ok := field.Tag.Lookup(key)
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("bind: field %s: invalid %s tag: %q", field.Name, key, value)
+	}
+	return b, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	values := strings.Split(s, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// Struct returns a Spec whose options store the parsed values to the fields
+// of the struct pointed to by ptr. The struct tags are interpreted by d.
+// Embedded structs are traversed. Default values are stored to the fields
+// immediately, and the fields are restored to them by Spec.Reset.
+//
+// Supported field types are string, bool, integers, floats, time.Duration,
+// types implementing flag.Value or encoding.TextUnmarshaler, and slices of
+// them, which accumulate the values.
+func Struct(ptr any, d Dialect) (*options.Spec, error) {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("bind: expected pointer to struct, got %T", ptr)
+	}
+	spec := &options.Spec{}
+	if err := bindStruct(spec, rv.Elem(), d); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func bindStruct(spec *options.Spec, rv reflect.Value, d Dialect) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(spec, fv, d); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		f, err := d.Option(field)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			continue
+		}
+		if len(f.Names) == 0 {
+			return fmt.Errorf("bind: field %s: no option names", field.Name)
+		}
+		o, err := bindField(fv, f)
+		if err != nil {
+			return fmt.Errorf("bind: field %s: %w", field.Name, err)
+		}
+		spec.Options = append(spec.Options, o)
+	}
+	return nil
+}
+
+var errUnsupported = errors.New("unsupported type")
+
+func bindField(fv reflect.Value, f *Field) (*options.OptionSpec, error) {
+	o := &f.OptionSpec
+	switch {
+	case f.Counter:
+		if !isInt(fv.Kind()) {
+			return nil, errors.New("counter requires an integer field")
+		}
+		o.Kind = options.Counter
+		o.ResetFunc = resetFunc(fv)
+		o.Func = func(string, []string) error {
+			fv.SetInt(fv.Int() + 1)
+			return nil
+		}
+		return o, nil
+	case isBool(fv):
+		if o.Kind == options.Unknown {
+			o.Kind = options.Boolean
+		}
+	case o.Kind == options.Unknown:
+		o.Kind = options.Required
+	}
+	if !isSupported(fv.Type()) {
+		return nil, fmt.Errorf("%w %v", errUnsupported, fv.Type())
+	}
+	if o.Default != "" {
+		if err := setValue(fv, o.Default); err != nil {
+			return nil, fmt.Errorf("invalid default value %q: %w", o.Default, err)
+		}
+	}
+	reset := fv.Kind() == reflect.Slice && o.Default != ""
+	restore := resetFunc(fv)
+	o.ResetFunc = func() {
+		restore()
+		reset = fv.Kind() == reflect.Slice && o.Default != ""
+	}
+	o.Func = func(_ string, values []string) error {
+		if reset {
+			fv.SetZero()
+			reset = false
+		}
+		if len(values) == 0 {
+			if isBool(fv) {
+				return setValue(fv, "true")
+			}
+			values = []string{""}
+		}
+		for _, value := range values {
+			if err := setValue(fv, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return o, nil
+}
+
+// resetFunc returns a function that restores fv to its current value.
+func resetFunc(fv reflect.Value) func() {
+	initial := reflect.New(fv.Type()).Elem()
+	initial.Set(fv)
+	return func() {
+		fv.Set(initial)
+	}
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isBool(fv reflect.Value) bool {
+	if fv.CanAddr() {
+		if bf, ok := fv.Addr().Interface().(interface{ IsBoolFlag() bool }); ok {
+			return bf.IsBoolFlag()
+		}
+	}
+	return fv.Kind() == reflect.Bool
+}
+
+// isSupported reports whether setValue can set a value of type t, without
+// calling the methods of t.
+func isSupported(t reflect.Type) bool {
+	if pt := reflect.PointerTo(t); pt.Implements(flagValueType) || pt.Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return isSupported(t.Elem())
+	default:
+		return false
+	}
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	flagValueType       = reflect.TypeFor[flag.Value]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func setValue(fv reflect.Value, s string) error {
+	if fv.CanAddr() {
+		switch v := fv.Addr().Interface().(type) {
+		case flag.Value:
+			return v.Set(s)
+		case encoding.TextUnmarshaler:
+			return v.UnmarshalText([]byte(s))
+		}
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(x)
+	case reflect.Slice:
+		ev := reflect.New(fv.Type().Elem()).Elem()
+		if err := setValue(ev, s); err != nil {
+			return err
+		}
+		fv.Set(reflect.Append(fv, ev))
+	default:
+		return fmt.Errorf("%w %v", errUnsupported, fv.Type())
+	}
+	return nil
+}
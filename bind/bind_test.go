@@ -0,0 +1,269 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package bind
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cions/go-options"
+)
+
+type Common struct {
+	Verbose int `option:"-v,--verbose" counter:"true" help:"increase verbosity"`
+}
+
+type NativeOptions struct {
+	Common
+	Name    string        `option:"-n,--name" help:"name" metavar:"NAME" default:"world"`
+	Color   string        `option:"--color" choices:"always,never,auto" default:"auto"`
+	Timeout time.Duration `option:"--timeout" default:"1s"`
+	Tags    []string      `option:"-t,--tag"`
+	Force   bool          `option:"-f,--force"`
+	Level   uint8         `option:"--level" env:"TEST_LEVEL" max:"1"`
+	Ignored string
+}
+
+func TestNative(t *testing.T) {
+	t.Setenv("TEST_LEVEL", "3")
+	opts := &NativeOptions{}
+	spec, err := Struct(opts, Native)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Name != "world" || opts.Color != "auto" || opts.Timeout != time.Second {
+		t.Errorf("defaults are not applied: %+v", opts)
+	}
+	_, args, err := spec.Parse([]string{"-vvf", "--name", "go", "arg", "-ta", "--tag=b", "--timeout=5m", "--verbose"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(args, []string{"arg"}) {
+		t.Errorf("args: expected [arg], got %v", args)
+	}
+	expected := NativeOptions{
+		Common:  Common{Verbose: 3},
+		Name:    "go",
+		Color:   "auto",
+		Timeout: 5 * time.Minute,
+		Tags:    []string{"a", "b"},
+		Force:   true,
+		Level:   3,
+	}
+	if !reflect.DeepEqual(*opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *opts)
+	}
+
+	_, _, err = spec.Parse([]string{"--level=256"})
+	if !errors.Is(err, options.ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+
+	spec.Reset()
+	_, _, err = spec.Parse([]string{"--level=1", "--level=2"})
+	if code := options.Code(err); code != options.CodeRepeatedOption {
+		t.Errorf("expected %v, got %#v", options.CodeRepeatedOption, err)
+	}
+
+	_, _, err = spec.Parse([]string{"--color=sometimes"})
+	if !errors.Is(err, options.ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+}
+
+type KongOptions struct {
+	Debug    bool     `short:"d" help:"enable debug"`
+	LogLevel string   `help:"log level" enum:"debug,info,warn" default:"info"`
+	Output   string   `name:"out" short:"o" placeholder:"FILE" required:""`
+	Verbose  int      `short:"v" type:"counter"`
+	Include  []string `aliases:"inc" hidden:""`
+	Files    []string `arg:""`
+	Internal string   `kong:"-"`
+}
+
+func TestKong(t *testing.T) {
+	opts := &KongOptions{}
+	spec, err := Struct(opts, Kong)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spec.Options) != 5 {
+		t.Fatalf("expected 5 options, got %v", len(spec.Options))
+	}
+	if o := spec.Lookup("--log-level"); o == nil || o.Help != "log level" || !slices.Equal(o.Choices, []string{"debug", "info", "warn"}) {
+		t.Errorf("--log-level: unexpected spec %+v", o)
+	}
+	if o := spec.Lookup("--out"); o == nil || o.Metavar != "FILE" || !o.Required {
+		t.Errorf("--out: unexpected spec %+v", o)
+	}
+	if o := spec.Lookup("--inc"); o == nil || !o.Hidden {
+		t.Errorf("--inc: unexpected spec %+v", o)
+	}
+
+	_, _, err = spec.Parse([]string{"-d", "-vv"})
+	if !errors.Is(err, options.ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+
+	opts = &KongOptions{}
+	spec, err = Struct(opts, Kong)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, args, err := spec.Parse([]string{"-dvo", "out.txt", "--log-level=warn", "--inc", "x", "file", "-v"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.Debug || opts.LogLevel != "warn" || opts.Output != "out.txt" || opts.Verbose != 2 || !slices.Equal(opts.Include, []string{"x"}) {
+		t.Errorf("unexpected values: %+v", opts)
+	}
+	if !slices.Equal(args, []string{"file"}) {
+		t.Errorf("args: expected [file], got %v", args)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	if _, err := Struct(KongOptions{}, Kong); err == nil {
+		t.Errorf("expected error for non-pointer")
+	}
+	var unsupported struct {
+		C chan int `option:"--chan"`
+	}
+	if _, err := Struct(&unsupported, Native); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+	var unsupportedSlice struct {
+		C []chan int `option:"--chan"`
+	}
+	if _, err := Struct(&unsupportedSlice, Native); err == nil {
+		t.Errorf("expected error for unsupported element type")
+	}
+	var badDefault struct {
+		N int `option:"--n" default:"x"`
+	}
+	if _, err := Struct(&badDefault, Native); err == nil {
+		t.Errorf("expected error for invalid default")
+	}
+	var badMax struct {
+		N int `option:"--n" max:"-1"`
+	}
+	if _, err := Struct(&badMax, Native); err == nil {
+		t.Errorf("expected error for invalid max")
+	}
+}
+
+// countingValue is a flag.Value that counts the calls to Set.
+type countingValue struct{ n *int }
+
+func (v countingValue) String() string { return "" }
+
+func (v countingValue) Set(string) error {
+	*v.n++
+	return nil
+}
+
+func TestStructNoSetBeforeParse(t *testing.T) {
+	var calls int
+	opts := struct {
+		V countingValue `option:"--value"`
+	}{countingValue{&calls}}
+	spec, err := Struct(&opts, Native)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Set called %d times before parsing", calls)
+	}
+	if _, _, err := spec.Parse([]string{"--value", "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call to Set, got %d", calls)
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"Verbose":    "verbose",
+		"LogLevel":   "log-level",
+		"HTTPServer": "http-server",
+		"UserID":     "user-id",
+	} {
+		if actual := kebabCase(in); actual != out {
+			t.Errorf("kebabCase(%q): expected %q, got %q", in, out, actual)
+		}
+	}
+}
+
+type GoArgOptions struct {
+	Verbose  bool     `arg:"-v" help:"verbose output"`
+	Output   string   `arg:"-o,--out,required" placeholder:"FILE"`
+	Workers  int      `arg:"env" default:"4"`
+	Token    string   `arg:"--api-token,env:API_TOKEN"`
+	Inputs   []string `arg:"positional"`
+	Internal string   `arg:"-"`
+}
+
+func TestGoArg(t *testing.T) {
+	t.Setenv("WORKERS", "8")
+	t.Setenv("API_TOKEN", "secret")
+	opts := &GoArgOptions{}
+	spec, err := Struct(opts, GoArg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spec.Options) != 4 {
+		t.Fatalf("expected 4 options, got %v", len(spec.Options))
+	}
+	if o := spec.Lookup("--verbose"); o == nil || !slices.Equal(o.Names, []string{"-v", "--verbose"}) || o.Help != "verbose output" {
+		t.Errorf("--verbose: unexpected spec %+v", o)
+	}
+	if opts.Workers != 4 {
+		t.Errorf("Workers: expected default 4, got %v", opts.Workers)
+	}
+
+	_, args, err := spec.Parse([]string{"-vo", "out.txt", "in1", "in2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := GoArgOptions{Verbose: true, Output: "out.txt", Workers: 8, Token: "secret"}
+	if !reflect.DeepEqual(*opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *opts)
+	}
+	if !slices.Equal(args, []string{"in1", "in2"}) {
+		t.Errorf("args: expected [in1 in2], got %v", args)
+	}
+
+	var bad struct {
+		X string `arg:"-x,bogus"`
+	}
+	if _, err := Struct(&bad, GoArg); err == nil {
+		t.Errorf("expected error for unknown tag item")
+	}
+}
+
+func TestReset(t *testing.T) {
+	opts := &NativeOptions{}
+	spec, err := Struct(opts, Native)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := spec.Parse([]string{"-vf", "--name=go", "-ta", "--level=1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec.Reset()
+	expected := NativeOptions{Name: "world", Color: "auto", Timeout: time.Second}
+	if !reflect.DeepEqual(*opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *opts)
+	}
+	if _, _, err := spec.Parse([]string{"-tb"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(opts.Tags, []string{"b"}) {
+		t.Errorf("tags: expected [b], got %v", opts.Tags)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GoArg is the dialect compatible with the struct tags of alexflint/go-arg:
+//
+//	arg:"-v,--verbose,required,env:VERBOSE"
+//	help:"..."             help message
+//	placeholder:"FILE"     name of the value in help messages
+//	default:"..."          default value
+//
+// Every exported field is an option named after the lowercased field name
+// unless the arg tag gives a long name or is "-". A bare "env" uses the
+// uppercased field name. Fields with positional or subcommand in the arg tag
+// are skipped.
+var GoArg Dialect = goArgDialect{}
+
+type goArgDialect struct{}
+
+func (goArgDialect) Option(field reflect.StructField) (*Field, error) {
+	tag := field.Tag.Get("arg")
+	if tag == "-" {
+		return nil, nil
+	}
+
+	f := &Field{}
+	short, long := "", "--"+strings.ToLower(field.Name)
+	for _, item := range splitList(tag) {
+		key, value, _ := strings.Cut(item, ":")
+		switch {
+		case strings.HasPrefix(item, "--"):
+			long = item
+		case strings.HasPrefix(item, "-"):
+			short = item
+		case key == "required":
+			f.Required = true
+		case key == "env":
+			f.Env = []string{value}
+			if value == "" {
+				f.Env[0] = strings.ToUpper(field.Name)
+			}
+		case key == "positional", key == "subcommand":
+			return nil, nil
+		case key == "separate":
+		default:
+			return nil, fmt.Errorf("bind: field %s: unknown arg tag item %q", field.Name, item)
+		}
+	}
+	if short != "" {
+		f.Names = append(f.Names, short)
+	}
+	f.Names = append(f.Names, long)
+	f.Help = field.Tag.Get("help")
+	f.Metavar = field.Tag.Get("placeholder")
+	f.Default = field.Tag.Get("default")
+	return f, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package bind
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Kong is the dialect compatible with the struct tags of alecthomas/kong and
+// kingpin-style annotations:
+//
+//	name:"verbose"         long name (default: the field name in kebab-case)
+//	short:"v"              short name
+//	aliases:"a,b"          additional long names
+//	help:"..."             help message
+//	placeholder:"FILE"     name of the value in help messages
+//	default:"..."          default value
+//	env:"FOO,BAR"          environment variables
+//	enum:"a,b,c"           permitted values
+//	required:""            the option must be specified
+//	hidden:""              hide from help and completion
+//	type:"counter"         an integer field counts the occurrences
+//
+// Every exported field is an option unless tagged kong:"-". Fields tagged
+// arg:"" (positional arguments) and cmd:"" (subcommands) are skipped. The
+// combined kong:"name='x',help='y'" form is not supported.
+var Kong Dialect = kongDialect{}
+
+type kongDialect struct{}
+
+func (kongDialect) Option(field reflect.StructField) (*Field, error) {
+	if field.Tag.Get("kong") == "-" {
+		return nil, nil
+	}
+	if _, ok := field.Tag.Lookup("arg"); ok {
+		return nil, nil
+	}
+	if _, ok := field.Tag.Lookup("cmd"); ok {
+		return nil, nil
+	}
+
+	f := &Field{}
+	name := field.Tag.Get("name")
+	if name == "" {
+		name = kebabCase(field.Name)
+	}
+	if short := field.Tag.Get("short"); short != "" {
+		f.Names = append(f.Names, "-"+short)
+	}
+	f.Names = append(f.Names, "--"+name)
+	for _, alias := range splitList(field.Tag.Get("aliases")) {
+		f.Names = append(f.Names, "--"+alias)
+	}
+	f.Help = field.Tag.Get("help")
+	f.Metavar = field.Tag.Get("placeholder")
+	f.Default = field.Tag.Get("default")
+	f.Env = splitList(field.Tag.Get("env"))
+	f.Choices = splitList(field.Tag.Get("enum"))
+	_, f.Required = field.Tag.Lookup("required")
+	_, f.Hidden = field.Tag.Lookup("hidden")
+	f.Counter = field.Tag.Get("type") == "counter"
+	return f, nil
+}
+
+func kebabCase(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteCarapace writes the spec as a carapace-spec YAML document to w.
+//
+// Carapace supports at most one short and one long name per flag; the other
+// names are omitted. Options of a command that has subcommands are exported
+// as persistent flags.
+func (s *Spec) WriteCarapace(w io.Writer) error {
+	s.init()
+	bw := bufio.NewWriter(w)
+	writeCarapaceCommand(bw, s, "")
+	return bw.Flush()
+}
+
+func writeCarapaceCommand(w *bufio.Writer, s *Spec, indent string) {
+	w.WriteString("name: " + strconv.Quote(s.Name) + "\n")
+	if len(s.Aliases) > 0 {
+		w.WriteString(indent + "aliases: " + yamlList(s.Aliases) + "\n")
+	}
+	if s.Summary != "" {
+		w.WriteString(indent + "description: " + strconv.Quote(s.Summary) + "\n")
+	}
+	if s.Hidden {
+		w.WriteString(indent + "hidden: true\n")
+	}
+
+	var flags []*OptionSpec
+	for _, o := range s.Options {
+		if o.Short() != "" || o.Long() != "" {
+			flags = append(flags, o)
+		}
+	}
+	if len(flags) > 0 {
+		if len(s.Commands) > 0 {
+			w.WriteString(indent + "persistentflags:\n")
+		} else {
+			w.WriteString(indent + "flags:\n")
+		}
+		for _, o := range flags {
+			w.WriteString(indent + "  " + strconv.Quote(carapaceFlag(o)) + ": " + strconv.Quote(o.Help) + "\n")
+		}
+	}
+
+	var flagCompletion []*OptionSpec
+	for _, o := range flags {
+		if !o.flag() && carapaceValues(o.Choices, o.completion()) != nil {
+			flagCompletion = append(flagCompletion, o)
+		}
+	}
+	var positionalAny []string
+	for _, a := range s.Positional {
+		if a.Variadic {
+			positionalAny = carapaceValues(a.Choices, a.Complete)
+			break
+		}
+	}
+	if len(flagCompletion) > 0 || len(s.Positional) > 0 {
+		w.WriteString(indent + "completion:\n")
+		if len(flagCompletion) > 0 {
+			w.WriteString(indent + "  flag:\n")
+			for _, o := range flagCompletion {
+				name := strings.TrimLeft(o.Long(), "-")
+				if name == "" {
+					name = strings.TrimLeft(o.Short(), "-")
+				}
+				w.WriteString(indent + "    " + strconv.Quote(name) + ": " + yamlList(carapaceValues(o.Choices, o.completion())) + "\n")
+			}
+		}
+		if len(s.Positional) > 0 && !s.Positional[0].Variadic {
+			w.WriteString(indent + "  positional:\n")
+			for _, a := range s.Positional {
+				if a.Variadic {
+					break
+				}
+				w.WriteString(indent + "    - " + yamlList(carapaceValues(a.Choices, a.Complete)) + "\n")
+			}
+		}
+		if positionalAny != nil {
+			w.WriteString(indent + "  positionalany: " + yamlList(positionalAny) + "\n")
+		}
+	}
+
+	if len(s.Commands) > 0 {
+		w.WriteString(indent + "commands:\n")
+		for _, cmd := range s.Commands {
+			w.WriteString(indent + "  - ")
+			writeCarapaceCommand(w, cmd, indent+"    ")
+		}
+	}
+}
+
+func carapaceFlag(o *OptionSpec) string {
+	var sb strings.Builder
+	short, long := o.Short(), o.Long()
+	sb.WriteString(short)
+	if short != "" && long != "" {
+		sb.WriteString(", ")
+	}
+	sb.WriteString(long)
+	switch o.kind().Base() {
+	case Boolean, Counter, Toggle:
+	case Optional:
+		sb.WriteString("?")
+	default:
+		sb.WriteString("=")
+	}
+	if o.Hidden {
+		sb.WriteString("&")
+	}
+	return sb.String()
+}
+
+func carapaceValues(choices []string, complete Completion) []string {
+	switch {
+	case len(choices) > 0:
+		return choices
+	case complete == CompleteFiles:
+		return []string{"$files"}
+	case complete == CompleteDirs:
+		return []string{"$directories"}
+	default:
+		return nil
+	}
+}
+
+func yamlList(values []string) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Quote(value))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
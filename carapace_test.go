@@ -0,0 +1,40 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteCarapace(t *testing.T) {
+	var sb strings.Builder
+	if err := newTestSpec().WriteCarapace(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `name: "example"
+description: "An example command"
+persistentflags:
+  "-v, --verbose": "verbose output"
+  "-f, --file=": "input file"
+  "--color?": "colorize output"
+completion:
+  flag:
+    "file": ["$files"]
+    "color": ["always", "never", "auto"]
+commands:
+  - name: "run"
+    aliases: ["r"]
+    description: "Run a command"
+    flags:
+      "-n, --dry-run": "do not run"
+    completion:
+      positional:
+        - []
+      positionalany: ["$files"]
+`
+	if actual := sb.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
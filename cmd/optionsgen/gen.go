@@ -0,0 +1,147 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/format"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cions/go-options"
+)
+
+var kindNames = map[options.Kind]string{
+	options.Boolean:     "options.Boolean",
+	options.Required:    "options.Required",
+	options.Optional:    "options.Optional",
+	options.TakeTwoArgs: "options.TakeTwoArgs",
+	options.Counter:     "options.Counter",
+	options.Rest:        "options.Rest",
+	options.Property:    "options.Property",
+	options.Toggle:      "options.Toggle",
+}
+
+var kindModifiers = []struct {
+	mod  options.Kind
+	expr string
+}{
+	{options.Once, "options.Once"},
+	{options.Prefix, "options.Prefix"},
+	{options.DashValue, "options.DashValue"},
+	{options.NoDashValue, "options.NoDashValue"},
+	{options.Deprecated, "options.Deprecated"},
+	{options.File, "options.File"},
+	{options.NewFile, "options.NewFile"},
+}
+
+// kindExpr returns the Go expression of kind, or "" if it is not supported.
+func kindExpr(kind options.Kind) string {
+	for _, m := range kindModifiers {
+		if kind&m.mod != 0 {
+			if base := kindExpr(kind &^ m.mod); base != "" {
+				return base + " | " + m.expr
+			}
+			return ""
+		}
+	}
+	if kind.Base() == options.TakeTwoArgs && kind != options.TakeTwoArgs {
+		return "options.TakeNArgs(" + strconv.Itoa(kind.NArgs()) + ")"
+	}
+	if terms := kind.Terminators(); terms != nil {
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = strconv.Quote(term)
+		}
+		return "options.TerminatedBy(" + strings.Join(quoted, ", ") + ")"
+	}
+	return kindNames[kind]
+}
+
+type entry struct {
+	name string
+	kind options.Kind
+}
+
+// generate writes the Go source of a function named fn in package pkg that
+// returns the Kind of the options of spec. Names are dispatched on their
+// length first and then by a switch on the name, so lookups take constant
+// time regardless of the number of options and need no setup at run time.
+// The names of Prefix options are then matched as prefixes, the longest
+// first, as Spec does.
+func generate(w io.Writer, spec *options.Spec, pkg, fn string) error {
+	var entries, prefixes []entry
+	seen := make(map[string]bool)
+	for _, o := range spec.Options {
+		kind := cmp.Or(o.Kind, options.Boolean)
+		if kindExpr(kind) == "" {
+			return fmt.Errorf("option %s: unsupported kind %d", o.Names[0], kind)
+		}
+		for _, name := range o.Names {
+			if seen[name] {
+				return fmt.Errorf("option %s is defined more than once", name)
+			}
+			seen[name] = true
+			if kind&options.Prefix != 0 {
+				prefixes = append(prefixes, entry{name, kind})
+			} else {
+				entries = append(entries, entry{name, kind})
+			}
+		}
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return cmp.Or(cmp.Compare(len(a.name), len(b.name)), cmp.Compare(a.kind, b.kind), strings.Compare(a.name, b.name))
+	})
+	slices.SortStableFunc(prefixes, func(a, b entry) int {
+		return cmp.Compare(len(b.name), len(a.name))
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by optionsgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if len(prefixes) > 0 {
+		buf.WriteString("import (\n\"strings\"\n\n\"github.com/cions/go-options\"\n)\n\n")
+	} else {
+		buf.WriteString("import \"github.com/cions/go-options\"\n\n")
+	}
+	fmt.Fprintf(&buf, "// %s returns the Kind of the option name.\n", fn)
+	fmt.Fprintf(&buf, "func %s(name string) options.Kind {\n", fn)
+	if len(entries) > 0 {
+		buf.WriteString("switch len(name) {\n")
+	}
+	for i := 0; i < len(entries); {
+		n := len(entries[i].name)
+		fmt.Fprintf(&buf, "case %d:\nswitch name {\n", n)
+		for i < len(entries) && len(entries[i].name) == n {
+			kind := entries[i].kind
+			buf.WriteString("case ")
+			for j := i; i < len(entries) && len(entries[i].name) == n && entries[i].kind == kind; i++ {
+				if i > j {
+					buf.WriteString(", ")
+				}
+				buf.WriteString(strconv.Quote(entries[i].name))
+			}
+			fmt.Fprintf(&buf, ":\nreturn %s\n", kindExpr(kind))
+		}
+		buf.WriteString("}\n")
+	}
+	if len(entries) > 0 {
+		buf.WriteString("}\n")
+	}
+	for _, e := range prefixes {
+		fmt.Fprintf(&buf, "if strings.HasPrefix(name, %s) {\nreturn %s\n}\n", strconv.Quote(e.name), kindExpr(e.kind))
+	}
+	buf.WriteString("return options.Unknown\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+func TestGenerate(t *testing.T) {
+	spec, err := options.ReadSchema(strings.NewReader(`{
+		"name": "example",
+		"options": [
+			{"names": ["-v", "--verbose"], "kind": "boolean"},
+			{"names": ["-f", "--file"], "kind": "required"},
+			{"names": ["--color"], "kind": "optional"},
+			{"names": ["-D", "--define"], "kind": "take-two-args"},
+			{"names": ["-q", "--quiet"], "kind": "boolean"},
+			{"names": ["--rect"], "kind": "take-4-args"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sb strings.Builder
+	if err := generate(&sb, spec, "example", "kindOf"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `// Code generated by optionsgen; DO NOT EDIT.
+
+package example
+
+import "github.com/cions/go-options"
+
+// kindOf returns the Kind of the option name.
+func kindOf(name string) options.Kind {
+	switch len(name) {
+	case 2:
+		switch name {
+		case "-q", "-v":
+			return options.Boolean
+		case "-f":
+			return options.Required
+		case "-D":
+			return options.TakeTwoArgs
+		}
+	case 6:
+		switch name {
+		case "--file":
+			return options.Required
+		case "--rect":
+			return options.TakeNArgs(4)
+		}
+	case 7:
+		switch name {
+		case "--quiet":
+			return options.Boolean
+		case "--color":
+			return options.Optional
+		}
+	case 8:
+		switch name {
+		case "--define":
+			return options.TakeTwoArgs
+		}
+	case 9:
+		switch name {
+		case "--verbose":
+			return options.Boolean
+		}
+	}
+	return options.Unknown
+}
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected output:\n%s", sb.String())
+	}
+}
+
+func TestGeneratePrefix(t *testing.T) {
+	spec, err := options.ReadSchema(strings.NewReader(`{
+		"name": "cc",
+		"options": [
+			{"names": ["-W"], "kind": "boolean|prefix"},
+			{"names": ["--warn-"], "kind": "optional|prefix"},
+			{"names": ["--warn-error-"], "kind": "boolean|prefix"},
+			{"names": ["--warn-error"], "kind": "required"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sb strings.Builder
+	if err := generate(&sb, spec, "cc", "kindOf"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `// Code generated by optionsgen; DO NOT EDIT.
+
+package cc
+
+import (
+	"strings"
+
+	"github.com/cions/go-options"
+)
+
+// kindOf returns the Kind of the option name.
+func kindOf(name string) options.Kind {
+	switch len(name) {
+	case 12:
+		switch name {
+		case "--warn-error":
+			return options.Required
+		}
+	}
+	if strings.HasPrefix(name, "--warn-error-") {
+		return options.Boolean | options.Prefix
+	}
+	if strings.HasPrefix(name, "--warn-") {
+		return options.Optional | options.Prefix
+	}
+	if strings.HasPrefix(name, "-W") {
+		return options.Boolean | options.Prefix
+	}
+	return options.Unknown
+}
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected output:\n%s", sb.String())
+	}
+}
+
+func TestGenerateDuplicate(t *testing.T) {
+	spec := &options.Spec{
+		Options: []*options.OptionSpec{
+			{Names: []string{"-v"}},
+			{Names: []string{"-v"}, Kind: options.Required},
+		},
+	}
+	var sb strings.Builder
+	if err := generate(&sb, spec, "main", "optionKind"); err == nil {
+		t.Errorf("expected error")
+	}
+}
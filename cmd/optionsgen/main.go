@@ -0,0 +1,91 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Command optionsgen generates a Go function returning the Kind of each
+// option described by a schema written by (*options.Spec).WriteSchema.
+//
+// Usage:
+//
+//	optionsgen [-p PACKAGE] [-f FUNC] [-o OUTPUT] [SCHEMA]
+//
+// The schema is read from the standard input if SCHEMA is omitted or -.
+// Only the options of the top-level command are included.
+//
+// A typical use is a go:generate directive:
+//
+//	//go:generate optionsgen -o kind_gen.go schema.json
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cions/go-options"
+)
+
+func newSpec() *options.Spec {
+	return &options.Spec{
+		Name:    "optionsgen",
+		Summary: "Generate an option Kind lookup function from a schema",
+		Options: []*options.OptionSpec{
+			{Names: []string{"-p", "--package"}, Kind: options.Required, Metavar: "PACKAGE", Default: "main", Help: "package name of the generated file"},
+			{Names: []string{"-f", "--func"}, Kind: options.Required, Metavar: "FUNC", Default: "optionKind", Help: "name of the generated function"},
+			{Names: []string{"-o", "--output"}, Kind: options.Required, Metavar: "OUTPUT", Complete: options.CompleteFiles, Help: "write to OUTPUT instead of the standard output"},
+			{Names: []string{"-h", "--help"}, Help: "show this help message and exit", Func: func(string, []string) error { return options.ErrHelp }},
+		},
+		Positional: []*options.ArgSpec{
+			{Name: "SCHEMA", Complete: options.CompleteFiles, Help: "schema file"},
+		},
+	}
+}
+
+func run(args []string) error {
+	spec := newSpec()
+	_, args, err := spec.Parse(args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return options.Errorf("too many arguments")
+	}
+
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 && args[0] != "-" {
+		fh, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		in = fh
+	}
+	schema, err := options.ReadSchema(in)
+	if err != nil {
+		return err
+	}
+
+	pkg, _ := spec.Lookup("--package").Value()
+	fn, _ := spec.Lookup("--func").Value()
+	out := io.Writer(os.Stdout)
+	if path, ok := spec.Lookup("--output").Value(); ok {
+		fh, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		out = fh
+	}
+	return generate(out, schema, pkg, fn)
+}
+
+func main() {
+	err := run(os.Args[1:])
+	if errors.Is(err, options.ErrHelp) {
+		newSpec().WriteHelp(os.Stdout, options.StandardHelp)
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "optionsgen: error: %v\n", err)
+		os.Exit(1)
+	}
+}
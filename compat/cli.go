@@ -0,0 +1,111 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cions/go-options"
+)
+
+// FromCLIFlags converts urfave/cli v2 flag definitions (e.g. []cli.Flag)
+// to OptionSpecs.
+//
+// The flags are read through reflection, so any struct (or pointer to
+// struct) with the fields Name, Aliases, Usage, EnvVars, Value, DefaultText,
+// Hidden and TakesFile is accepted; missing fields are ignored. Names of a
+// single character become short options, and the others long options.
+// Flags whose type name contains "Bool" are Boolean, and the others are
+// Required.
+func FromCLIFlags[F any](flags []F) ([]*options.OptionSpec, error) {
+	var specs []*options.OptionSpec
+	for _, flag := range flags {
+		rv := reflect.ValueOf(flag)
+		for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Pointer {
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("compat: unsupported flag type %T", flag)
+		}
+		name := stringField(rv, "Name")
+		if name == "" {
+			return nil, fmt.Errorf("compat: flag %T has no name", flag)
+		}
+
+		o := &options.OptionSpec{
+			Kind:    options.Required,
+			Metavar: strings.ToUpper(name),
+			Help:    stringField(rv, "Usage"),
+			Env:     stringsField(rv, "EnvVars"),
+			Hidden:  boolField(rv, "Hidden"),
+		}
+		for _, name := range append([]string{name}, stringsField(rv, "Aliases")...) {
+			if len(name) == 1 {
+				o.Names = append(o.Names, "-"+name)
+			} else {
+				o.Names = append(o.Names, "--"+name)
+			}
+		}
+		if strings.Contains(rv.Type().Name(), "Bool") {
+			o.Kind = options.Boolean
+		}
+		if boolField(rv, "TakesFile") {
+			o.Complete = options.CompleteFiles
+		}
+		if text := stringField(rv, "DefaultText"); text != "" {
+			o.Default = text
+		} else if v := rv.FieldByName("Value"); v.IsValid() && !v.IsZero() && o.Kind != options.Boolean {
+			o.Default = defaultString(v)
+		}
+		specs = append(specs, o)
+	}
+	return specs, nil
+}
+
+// defaultString formats the Value of a flag. The String method of the value
+// is used if any. Otherwise pointers are dereferenced, and the elements of a
+// slice are joined with commas.
+func defaultString(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return defaultString(v.Elem())
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = defaultString(v.Index(i))
+		}
+		return strings.Join(elems, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func stringField(rv reflect.Value, name string) string {
+	if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+func stringsField(rv reflect.Value, name string) []string {
+	if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String {
+		return f.Convert(reflect.TypeFor[[]string]()).Interface().([]string)
+	}
+	return nil
+}
+
+func boolField(rv reflect.Value, name string) bool {
+	if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.Bool {
+		return f.Bool()
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cions/go-options"
+)
+
+type cliFlag interface {
+	Names() []string
+}
+
+type BoolFlag struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	EnvVars []string
+	Value   bool
+}
+
+func (f *BoolFlag) Names() []string { return append([]string{f.Name}, f.Aliases...) }
+
+type StringFlag struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	EnvVars     []string
+	TakesFile   bool
+	Value       string
+	DefaultText string
+	Hidden      bool
+}
+
+func (f *StringFlag) Names() []string { return append([]string{f.Name}, f.Aliases...) }
+
+type DurationFlag struct {
+	Name  string
+	Value time.Duration
+}
+
+func (f *DurationFlag) Names() []string { return []string{f.Name} }
+
+type StringSliceFlag struct {
+	Name  string
+	Value *[]string
+}
+
+func (f *StringSliceFlag) Names() []string { return []string{f.Name} }
+
+type IntSliceFlag struct {
+	Name  string
+	Value *IntSlice
+}
+
+func (f *IntSliceFlag) Names() []string { return []string{f.Name} }
+
+type IntSlice struct {
+	slice []int
+}
+
+func (s *IntSlice) String() string { return fmt.Sprint(s.slice) }
+
+func TestFromCLIFlags(t *testing.T) {
+	specs, err := FromCLIFlags([]cliFlag{
+		&BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "verbose output", EnvVars: []string{"VERBOSE"}},
+		&StringFlag{Name: "config", Aliases: []string{"c", "conf"}, Usage: "config file", TakesFile: true, Value: "app.toml"},
+		&StringFlag{Name: "token", DefaultText: "none", Hidden: true},
+		&DurationFlag{Name: "timeout", Value: 3 * time.Second},
+		&StringSliceFlag{Name: "tag", Value: &[]string{"a", "b"}},
+		&IntSliceFlag{Name: "port", Value: &IntSlice{[]int{80, 443}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specs) != 6 {
+		t.Fatalf("expected 6 specs, got %v", len(specs))
+	}
+	if o := specs[0]; !slices.Equal(o.Names, []string{"--verbose", "-v"}) || o.Kind != options.Boolean || o.Help != "verbose output" || !slices.Equal(o.Env, []string{"VERBOSE"}) {
+		t.Errorf("verbose: unexpected spec %+v", o)
+	}
+	if o := specs[1]; !slices.Equal(o.Names, []string{"--config", "-c", "--conf"}) || o.Kind != options.Required || o.Default != "app.toml" || o.Complete != options.CompleteFiles {
+		t.Errorf("config: unexpected spec %+v", o)
+	}
+	if o := specs[2]; o.Default != "none" || !o.Hidden {
+		t.Errorf("token: unexpected spec %+v", o)
+	}
+	if o := specs[3]; o.Default != "3s" {
+		t.Errorf("timeout: unexpected spec %+v", o)
+	}
+	if o := specs[4]; o.Default != "a,b" {
+		t.Errorf("tag: expected default a,b, got %q", o.Default)
+	}
+	if o := specs[5]; o.Default != "[80 443]" {
+		t.Errorf("port: expected default [80 443], got %q", o.Default)
+	}
+
+	if _, err := FromCLIFlags([]any{42}); err == nil {
+		t.Errorf("expected error for unsupported flag type")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"errors"
+
+	"github.com/cions/go-options"
+)
+
+// CobraRunE returns a function suitable for cobra.Command.RunE that parses
+// the arguments with opts and calls run with the positional arguments.
+//
+// The command must have DisableFlagParsing set so that cobra passes the raw
+// arguments through. If parsing returns ErrHelp and the command has a Help
+// method (as *cobra.Command does), Help is called instead of run.
+//
+//	cmd := &cobra.Command{
+//		Use:                "example",
+//		DisableFlagParsing: true,
+//		RunE: compat.CobraRunE(opts, func(cmd *cobra.Command, args []string) error {
+//			...
+//		}),
+//	}
+func CobraRunE[C any](opts options.Options, run func(cmd C, args []string) error) func(C, []string) error {
+	return func(cmd C, args []string) error {
+		args, err := options.Parse(opts, args)
+		if errors.Is(err, options.ErrHelp) {
+			if h, ok := any(cmd).(interface{ Help() error }); ok {
+				return h.Help()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+type fakeCommand struct {
+	helped bool
+}
+
+func (c *fakeCommand) Help() error {
+	c.helped = true
+	return nil
+}
+
+func TestCobraRunE(t *testing.T) {
+	newSpec := func() *options.Spec {
+		return &options.Spec{
+			Options: []*options.OptionSpec{
+				{Names: []string{"-v", "--verbose"}},
+				{Names: []string{"-h", "--help"}, Func: func(string, []string) error { return options.ErrHelp }},
+			},
+		}
+	}
+
+	spec := newSpec()
+	var got []string
+	runE := CobraRunE(spec, func(cmd *fakeCommand, args []string) error {
+		got = args
+		return nil
+	})
+	cmd := &fakeCommand{}
+	if err := runE(cmd, []string{"a", "-v", "--", "-b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, []string{"a", "-b"}) {
+		t.Errorf("args: expected [a -b], got %v", got)
+	}
+	if spec.Lookup("-v").Count() != 1 {
+		t.Errorf("-v: expected 1")
+	}
+
+	if err := runE(cmd, []string{"--help"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !cmd.helped {
+		t.Errorf("Help was not called")
+	}
+
+	if err := runE(cmd, []string{"--unknown"}); !errors.Is(err, options.ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+}
@@ -0,0 +1,6 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Package compat provides adapters for migrating programs from other
+// command-line parsing libraries to the options package.
+package compat
@@ -0,0 +1,483 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cions/go-options"
+)
+
+// PFlag is a flag registered to a PFlagSet, mirroring pflag.Flag.
+type PFlag struct {
+	Name        string
+	Shorthand   string
+	Usage       string
+	DefValue    string
+	NoOptDefVal string
+	Hidden      bool
+
+	// Value is the value of the flag.
+	Value flag.Value
+
+	isBool  bool
+	changed bool
+	spec    *options.OptionSpec
+}
+
+// PFlagSet is a set of flags with the registration API of spf13/pflag.
+// The flags are added to Spec, which does the parsing.
+//
+// Bool and count flags are Boolean options, which accept a value as in
+// --flag=false. A flag with a NoOptDefVal is an Optional option.
+type PFlagSet struct {
+	Spec *options.Spec
+
+	flags map[string]*PFlag
+	order []*PFlag
+	args  []string
+}
+
+// NewPFlagSet returns a new PFlagSet with an empty spec named name.
+func NewPFlagSet(name string) *PFlagSet {
+	return &PFlagSet{Spec: &options.Spec{Name: name}}
+}
+
+// Lookup returns the flag with the given name, or nil if not found.
+func (f *PFlagSet) Lookup(name string) *PFlag {
+	return f.flags[name]
+}
+
+// MarkHidden hides the flag from help and completion.
+func (f *PFlagSet) MarkHidden(name string) error {
+	pf := f.flags[name]
+	if pf == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	pf.Hidden = true
+	return nil
+}
+
+// Changed reports whether the flag was specified on the command line.
+func (f *PFlagSet) Changed(name string) bool {
+	pf := f.flags[name]
+	return pf != nil && pf.changed
+}
+
+// Parse parses the command line with Spec, using a Parser with BoolValues.
+// Changes to NoOptDefVal and Hidden of the flags made after registration take
+// effect.
+func (f *PFlagSet) Parse(args []string) error {
+	for _, pf := range f.order {
+		switch {
+		case pf.isBool:
+			pf.spec.Kind = options.Boolean
+		case pf.NoOptDefVal != "":
+			pf.spec.Kind = options.Optional
+		default:
+			pf.spec.Kind = options.Required
+		}
+		pf.spec.Hidden = pf.Hidden
+	}
+	args, err := (&options.Parser{BoolValues: true}).Parse(f.Spec, args)
+	f.args = args
+	return err
+}
+
+// Args returns the positional arguments after Parse.
+func (f *PFlagSet) Args() []string {
+	return f.args
+}
+
+// Var defines a flag with the specified name and usage.
+func (f *PFlagSet) Var(value flag.Value, name, usage string) {
+	f.VarP(value, name, "", usage)
+}
+
+// VarP is like Var, but accepts a shorthand letter.
+func (f *PFlagSet) VarP(value flag.Value, name, shorthand, usage string) {
+	f.VarPF(value, name, shorthand, usage)
+}
+
+// VarPF is like VarP, but returns the flag created.
+func (f *PFlagSet) VarPF(value flag.Value, name, shorthand, usage string) *PFlag {
+	pf := &PFlag{
+		Name:      name,
+		Shorthand: shorthand,
+		Usage:     usage,
+		DefValue:  value.String(),
+		Value:     value,
+	}
+	if bf, ok := value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		pf.isBool = true
+	}
+	pf.spec = &options.OptionSpec{
+		Names:   []string{"--" + name},
+		Kind:    options.Required,
+		Metavar: strings.ToUpper(name),
+		Help:    usage,
+		Default: pf.DefValue,
+		Func: func(_ string, values []string) error {
+			pf.changed = true
+			switch {
+			case len(values) > 0:
+				return pf.Value.Set(values[0])
+			case pf.NoOptDefVal != "":
+				return pf.Value.Set(pf.NoOptDefVal)
+			default:
+				return pf.Value.Set("true")
+			}
+		},
+		ResetFunc: func() {
+			pf.changed = false
+			if sv, ok := pf.Value.(*sliceValue); ok {
+				sv.changed = false
+			}
+		},
+	}
+	if shorthand != "" {
+		pf.spec.Names = []string{"-" + shorthand, "--" + name}
+	}
+	if f.flags == nil {
+		f.flags = make(map[string]*PFlag)
+	}
+	f.flags[name] = pf
+	f.order = append(f.order, pf)
+	// Merge makes the spec match the new flag even after a Parse.
+	f.Spec.Merge(&options.Spec{Options: []*options.OptionSpec{pf.spec}})
+	return pf
+}
+
+type value[T any] struct {
+	p      *T
+	parse  func(string) (T, error)
+	format func(T) string
+	isBool bool
+}
+
+func (v *value[T]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return v.format(*v.p)
+}
+
+func (v *value[T]) Set(s string) error {
+	x, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+	*v.p = x
+	return nil
+}
+
+func (v *value[T]) IsBoolFlag() bool {
+	return v.isBool
+}
+
+func newValue[T any](p *T, def T, parse func(string) (T, error), format func(T) string) *value[T] {
+	*p = def
+	return &value[T]{p: p, parse: parse, format: format}
+}
+
+func parseInt(s string) (int, error) {
+	n, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	return int(n), err
+}
+
+func parseInt64(s string) (int64, error) {
+	return strconv.ParseInt(s, 0, 64)
+}
+
+func parseUint(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	return uint(n), err
+}
+
+func parseFloat64(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func formatFloat64(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func formatInt(x int) string {
+	return strconv.Itoa(x)
+}
+
+func formatUint(x uint) string {
+	return strconv.FormatUint(uint64(x), 10)
+}
+
+func formatInt64(x int64) string {
+	return strconv.FormatInt(x, 10)
+}
+
+func formatString(s string) string {
+	return s
+}
+
+// BoolVarP defines a bool flag with a shorthand letter.
+func (f *PFlagSet) BoolVarP(p *bool, name, shorthand string, value bool, usage string) {
+	v := newValue(p, value, strconv.ParseBool, strconv.FormatBool)
+	v.isBool = true
+	f.VarPF(v, name, shorthand, usage).NoOptDefVal = "true"
+}
+
+// BoolVar defines a bool flag.
+func (f *PFlagSet) BoolVar(p *bool, name string, value bool, usage string) {
+	f.BoolVarP(p, name, "", value, usage)
+}
+
+// BoolP defines a bool flag with a shorthand letter.
+func (f *PFlagSet) BoolP(name, shorthand string, value bool, usage string) *bool {
+	p := new(bool)
+	f.BoolVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Bool defines a bool flag.
+func (f *PFlagSet) Bool(name string, value bool, usage string) *bool {
+	return f.BoolP(name, "", value, usage)
+}
+
+// CountVarP defines a count flag with a shorthand letter. Each occurrence
+// increments the value.
+func (f *PFlagSet) CountVarP(p *int, name, shorthand, usage string) {
+	v := newValue(p, 0, func(string) (int, error) { return *p + 1, nil }, formatInt)
+	v.isBool = true
+	f.VarPF(v, name, shorthand, usage).NoOptDefVal = "+1"
+}
+
+// CountVar defines a count flag.
+func (f *PFlagSet) CountVar(p *int, name, usage string) {
+	f.CountVarP(p, name, "", usage)
+}
+
+// CountP defines a count flag with a shorthand letter.
+func (f *PFlagSet) CountP(name, shorthand, usage string) *int {
+	p := new(int)
+	f.CountVarP(p, name, shorthand, usage)
+	return p
+}
+
+// Count defines a count flag.
+func (f *PFlagSet) Count(name, usage string) *int {
+	return f.CountP(name, "", usage)
+}
+
+// StringVarP defines a string flag with a shorthand letter.
+func (f *PFlagSet) StringVarP(p *string, name, shorthand, value, usage string) {
+	f.VarP(newValue(p, value, parseString, formatString), name, shorthand, usage)
+}
+
+// StringVar defines a string flag.
+func (f *PFlagSet) StringVar(p *string, name, value, usage string) {
+	f.StringVarP(p, name, "", value, usage)
+}
+
+// StringP defines a string flag with a shorthand letter.
+func (f *PFlagSet) StringP(name, shorthand, value, usage string) *string {
+	p := new(string)
+	f.StringVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// String defines a string flag.
+func (f *PFlagSet) String(name, value, usage string) *string {
+	return f.StringP(name, "", value, usage)
+}
+
+// IntVarP defines an int flag with a shorthand letter.
+func (f *PFlagSet) IntVarP(p *int, name, shorthand string, value int, usage string) {
+	f.VarP(newValue(p, value, parseInt, formatInt), name, shorthand, usage)
+}
+
+// IntVar defines an int flag.
+func (f *PFlagSet) IntVar(p *int, name string, value int, usage string) {
+	f.IntVarP(p, name, "", value, usage)
+}
+
+// IntP defines an int flag with a shorthand letter.
+func (f *PFlagSet) IntP(name, shorthand string, value int, usage string) *int {
+	p := new(int)
+	f.IntVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Int defines an int flag.
+func (f *PFlagSet) Int(name string, value int, usage string) *int {
+	return f.IntP(name, "", value, usage)
+}
+
+// Int64VarP defines an int64 flag with a shorthand letter.
+func (f *PFlagSet) Int64VarP(p *int64, name, shorthand string, value int64, usage string) {
+	f.VarP(newValue(p, value, parseInt64, formatInt64), name, shorthand, usage)
+}
+
+// Int64Var defines an int64 flag.
+func (f *PFlagSet) Int64Var(p *int64, name string, value int64, usage string) {
+	f.Int64VarP(p, name, "", value, usage)
+}
+
+// Int64P defines an int64 flag with a shorthand letter.
+func (f *PFlagSet) Int64P(name, shorthand string, value int64, usage string) *int64 {
+	p := new(int64)
+	f.Int64VarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Int64 defines an int64 flag.
+func (f *PFlagSet) Int64(name string, value int64, usage string) *int64 {
+	return f.Int64P(name, "", value, usage)
+}
+
+// UintVarP defines a uint flag with a shorthand letter.
+func (f *PFlagSet) UintVarP(p *uint, name, shorthand string, value uint, usage string) {
+	f.VarP(newValue(p, value, parseUint, formatUint), name, shorthand, usage)
+}
+
+// UintVar defines a uint flag.
+func (f *PFlagSet) UintVar(p *uint, name string, value uint, usage string) {
+	f.UintVarP(p, name, "", value, usage)
+}
+
+// UintP defines a uint flag with a shorthand letter.
+func (f *PFlagSet) UintP(name, shorthand string, value uint, usage string) *uint {
+	p := new(uint)
+	f.UintVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Uint defines a uint flag.
+func (f *PFlagSet) Uint(name string, value uint, usage string) *uint {
+	return f.UintP(name, "", value, usage)
+}
+
+// Float64VarP defines a float64 flag with a shorthand letter.
+func (f *PFlagSet) Float64VarP(p *float64, name, shorthand string, value float64, usage string) {
+	f.VarP(newValue(p, value, parseFloat64, formatFloat64), name, shorthand, usage)
+}
+
+// Float64Var defines a float64 flag.
+func (f *PFlagSet) Float64Var(p *float64, name string, value float64, usage string) {
+	f.Float64VarP(p, name, "", value, usage)
+}
+
+// Float64P defines a float64 flag with a shorthand letter.
+func (f *PFlagSet) Float64P(name, shorthand string, value float64, usage string) *float64 {
+	p := new(float64)
+	f.Float64VarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Float64 defines a float64 flag.
+func (f *PFlagSet) Float64(name string, value float64, usage string) *float64 {
+	return f.Float64P(name, "", value, usage)
+}
+
+// DurationVarP defines a time.Duration flag with a shorthand letter.
+func (f *PFlagSet) DurationVarP(p *time.Duration, name, shorthand string, value time.Duration, usage string) {
+	f.VarP(newValue(p, value, time.ParseDuration, time.Duration.String), name, shorthand, usage)
+}
+
+// DurationVar defines a time.Duration flag.
+func (f *PFlagSet) DurationVar(p *time.Duration, name string, value time.Duration, usage string) {
+	f.DurationVarP(p, name, "", value, usage)
+}
+
+// DurationP defines a time.Duration flag with a shorthand letter.
+func (f *PFlagSet) DurationP(name, shorthand string, value time.Duration, usage string) *time.Duration {
+	p := new(time.Duration)
+	f.DurationVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// Duration defines a time.Duration flag.
+func (f *PFlagSet) Duration(name string, value time.Duration, usage string) *time.Duration {
+	return f.DurationP(name, "", value, usage)
+}
+
+type sliceValue struct {
+	p       *[]string
+	split   bool
+	changed bool
+}
+
+func (v *sliceValue) String() string {
+	if v.p == nil {
+		return "[]"
+	}
+	return "[" + strings.Join(*v.p, ",") + "]"
+}
+
+func (v *sliceValue) Set(s string) error {
+	values := []string{s}
+	if v.split {
+		values = strings.Split(s, ",")
+	}
+	if !v.changed {
+		*v.p = nil
+		v.changed = true
+	}
+	*v.p = append(*v.p, values...)
+	return nil
+}
+
+// StringSliceVarP defines a []string flag with a shorthand letter. Values
+// are split on commas and accumulated across occurrences.
+func (f *PFlagSet) StringSliceVarP(p *[]string, name, shorthand string, value []string, usage string) {
+	*p = value
+	f.VarP(&sliceValue{p: p, split: true}, name, shorthand, usage)
+}
+
+// StringSliceVar defines a []string flag.
+func (f *PFlagSet) StringSliceVar(p *[]string, name string, value []string, usage string) {
+	f.StringSliceVarP(p, name, "", value, usage)
+}
+
+// StringSliceP defines a []string flag with a shorthand letter.
+func (f *PFlagSet) StringSliceP(name, shorthand string, value []string, usage string) *[]string {
+	p := new([]string)
+	f.StringSliceVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// StringSlice defines a []string flag.
+func (f *PFlagSet) StringSlice(name string, value []string, usage string) *[]string {
+	return f.StringSliceP(name, "", value, usage)
+}
+
+// StringArrayVarP defines a []string flag with a shorthand letter. Values
+// are accumulated across occurrences without splitting.
+func (f *PFlagSet) StringArrayVarP(p *[]string, name, shorthand string, value []string, usage string) {
+	*p = value
+	f.VarP(&sliceValue{p: p}, name, shorthand, usage)
+}
+
+// StringArrayVar defines a []string flag.
+func (f *PFlagSet) StringArrayVar(p *[]string, name string, value []string, usage string) {
+	f.StringArrayVarP(p, name, "", value, usage)
+}
+
+// StringArrayP defines a []string flag with a shorthand letter.
+func (f *PFlagSet) StringArrayP(name, shorthand string, value []string, usage string) *[]string {
+	p := new([]string)
+	f.StringArrayVarP(p, name, shorthand, value, usage)
+	return p
+}
+
+// StringArray defines a []string flag.
+func (f *PFlagSet) StringArray(name string, value []string, usage string) *[]string {
+	return f.StringArrayP(name, "", value, usage)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package compat
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cions/go-options"
+)
+
+func TestPFlagSet(t *testing.T) {
+	fs := NewPFlagSet("example")
+	verbose := fs.CountP("verbose", "v", "verbosity")
+	all := fs.BoolP("all", "a", false, "show all")
+	name := fs.StringP("name", "n", "default", "name")
+	color := fs.String("color", "auto", "colorize")
+	fs.Lookup("color").NoOptDefVal = "always"
+	timeout := fs.Duration("timeout", time.Second, "timeout")
+	tags := fs.StringSliceP("tag", "t", []string{"x"}, "tags")
+	var number int
+	fs.IntVar(&number, "number", 1, "number")
+
+	if *name != "default" || number != 1 || !slices.Equal(*tags, []string{"x"}) {
+		t.Errorf("unexpected defaults")
+	}
+
+	err := fs.Parse([]string{"-vvan", "foo", "arg", "--color", "--timeout=5s", "-ta,b", "-t", "c", "--number", "0x10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *verbose != 2 || !*all || *name != "foo" || *color != "always" || *timeout != 5*time.Second || number != 16 {
+		t.Errorf("unexpected values: %v %v %v %v %v %v", *verbose, *all, *name, *color, *timeout, number)
+	}
+	if !slices.Equal(*tags, []string{"a", "b", "c"}) {
+		t.Errorf("tags: expected [a b c], got %v", *tags)
+	}
+	if !slices.Equal(fs.Args(), []string{"arg"}) {
+		t.Errorf("Args: expected [arg], got %v", fs.Args())
+	}
+	if !fs.Changed("name") || !fs.Changed("number") || !fs.Changed("all") {
+		t.Errorf("Changed: unexpected result")
+	}
+	if fs.Lookup("name").DefValue != "default" {
+		t.Errorf("DefValue: expected default, got %v", fs.Lookup("name").DefValue)
+	}
+
+	fs = NewPFlagSet("example")
+	fs.Int("number", 0, "number")
+	if err := fs.Parse([]string{"--number=NaN"}); !errors.Is(err, options.ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+	if err := fs.MarkHidden("number"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := fs.MarkHidden("unknown"); err == nil {
+		t.Errorf("expected error for unknown flag")
+	}
+
+	fs = NewPFlagSet("example")
+	all = fs.BoolP("all", "a", true, "show all")
+	if err := fs.Parse([]string{"--all=false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *all || !fs.Changed("all") {
+		t.Errorf("all: expected false and changed, got %v, %v", *all, fs.Changed("all"))
+	}
+	tags = fs.StringSlice("tag", []string{"x"}, "tags")
+	if err := fs.Parse([]string{"--tag=a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fs.Spec.Reset()
+	if fs.Changed("all") || fs.Changed("tag") {
+		t.Errorf("Changed: expected false after Reset")
+	}
+	if err := fs.Parse([]string{"--tag=b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(*tags, []string{"b"}) {
+		t.Errorf("tags: expected [b], got %v", *tags)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"cmp"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Complete returns the completion candidates for the last element of args,
+// which is the word under the cursor (possibly empty). args should not
+// include the command name.
+//
+// Programs typically call Complete from a hidden subcommand invoked by the
+// shell completion script and print the candidates one per line.
+func (s *Spec) Complete(args []string) []string {
+	s.init()
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+	args = args[:len(args)-1]
+
+	cmd := s
+	var pending *OptionSpec
+	var npending, npos int
+	var ddash bool
+	for _, arg := range args {
+		if npending > 0 {
+			npending--
+			if slices.Contains(pending.kind().terminators(), arg) {
+				npending = 0
+			}
+			continue
+		}
+		switch {
+		case ddash, arg == "-", !strings.HasPrefix(arg, "-"):
+			if !ddash && npos == 0 && len(cmd.Commands) > 0 {
+				if sub := cmd.Command(arg); sub != nil {
+					cmd = sub
+					continue
+				}
+			}
+			npos++
+		case arg == "--":
+			ddash = true
+		case strings.HasPrefix(arg, "--"):
+			if o := cmd.Lookup(arg); o != nil {
+				pending, npending = o, valueCount(o.kind())
+			}
+		default:
+			for i := 1; i < len(arg); i++ {
+				o := cmd.Lookup("-" + arg[i:i+1])
+				if o == nil || o.flag() {
+					continue
+				}
+				if i == len(arg)-1 {
+					pending, npending = o, valueCount(o.kind())
+				} else {
+					pending, npending = o, valueCount(o.kind())-1
+				}
+				break
+			}
+		}
+	}
+
+	switch {
+	case npending > 0:
+		return completeValues(pending.Choices, pending.completion(), current)
+	case !ddash && strings.HasPrefix(current, "--") && strings.Contains(current, "="):
+		name, value, _ := strings.Cut(current, "=")
+		o := cmd.Lookup(name)
+		if o == nil || o.flag() {
+			return nil
+		}
+		candidates := completeValues(o.Choices, o.completion(), value)
+		for i := range candidates {
+			candidates[i] = name + "=" + candidates[i]
+		}
+		return candidates
+	case !ddash && strings.HasPrefix(current, "-"):
+		var candidates []string
+		for c := cmd; c != nil; c = c.parent {
+			for _, o := range c.Options {
+				if o.Hidden {
+					continue
+				}
+				for _, name := range o.Names {
+					if strings.HasPrefix(name, current) {
+						candidates = append(candidates, name)
+					}
+				}
+			}
+		}
+		return candidates
+	case !ddash && npos == 0 && len(cmd.Commands) > 0:
+		var candidates []string
+		for _, sub := range cmd.Commands {
+			if !sub.Hidden && strings.HasPrefix(sub.Name, current) {
+				candidates = append(candidates, sub.Name)
+			}
+		}
+		return candidates
+	default:
+		for i, a := range cmd.Positional {
+			if i == npos || a.Variadic {
+				return completeValues(a.Choices, a.Complete, current)
+			}
+		}
+		return nil
+	}
+}
+
+func valueCount(kind Kind) int {
+	switch kind.Base() {
+	case Required, Property, TakeTwoArgs:
+		return kind.NArgs()
+	case Rest, Terminated:
+		return math.MaxInt
+	default:
+		return 0
+	}
+}
+
+func completeValues(choices []string, complete Completion, prefix string) []string {
+	var candidates []string
+	for _, choice := range choices {
+		if strings.HasPrefix(choice, prefix) {
+			candidates = append(candidates, choice)
+		}
+	}
+	if complete == CompleteFiles || complete == CompleteDirs {
+		candidates = append(candidates, completeFiles(prefix, complete == CompleteDirs)...)
+	}
+	return candidates
+}
+
+func completeFiles(prefix string, dirsOnly bool) []string {
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(cmp.Or(dir, "."))
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (base == "" && strings.HasPrefix(name, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			candidates = append(candidates, dir+name+string(filepath.Separator))
+		} else if !dirsOnly {
+			candidates = append(candidates, dir+name)
+		}
+	}
+	slices.Sort(candidates)
+	return candidates
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	dir := t.TempDir() + string(filepath.Separator)
+	for _, name := range []string{"a.txt", "b.txt", ".hidden"} {
+		if err := os.WriteFile(dir+name, nil, 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(dir+"sub", 0o777); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := newTestSpec()
+	CompareSlice(t, "files", spec.Complete([]string{"-f", dir}), []string{
+		dir + "a.txt", dir + "b.txt", dir + "sub" + string(filepath.Separator),
+	})
+	CompareSlice(t, "attached", spec.Complete([]string{"--file=" + dir + "a"}), []string{
+		"--file=" + dir + "a.txt",
+	})
+	CompareSlice(t, "variadic", spec.Complete([]string{"run", "cat", "x", dir + "b"}), []string{
+		dir + "b.txt",
+	})
+	CompareSlice(t, "after --", spec.Complete([]string{"run", "--", "-"}), []string{})
+}
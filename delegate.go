@@ -0,0 +1,127 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strconv"
+	"strings"
+)
+
+type delegateOptions struct {
+	parent Options
+	prefix string
+	child  Options
+}
+
+// Delegate returns an Options that routes the long options whose names start
+// with prefix (e.g. "--docker-") to child with the prefix replaced by "--",
+// so that --docker-host is passed to child as --host. The other options and
+// the positional arguments are passed to parent. Delegate can be nested to
+// route several prefixes.
+//
+// The returned Options implements OptionsWithOptionN, OptionsWithCount,
+// OptionsWithProperty, OptionsWithToggle, OptionsWithSecret,
+// OptionsWithDeprecation, OptionsWithNames, OptionsWithArg and
+// OptionsWithArgs. The calls are forwarded if the receiver implements the
+// corresponding interface, and fall back to what Parse does otherwise. The
+// names returned by OptionNames are those of parent and the long names of
+// child with the prefix.
+func Delegate(parent Options, prefix string, child Options) Options {
+	return &delegateOptions{parent, prefix, child}
+}
+
+func (d *delegateOptions) route(name string) (Options, string) {
+	if rest, ok := strings.CutPrefix(name, d.prefix); ok && rest != "" {
+		return d.child, "--" + rest
+	}
+	return d.parent, name
+}
+
+func (d *delegateOptions) Kind(name string) Kind {
+	opts, name := d.route(name)
+	return opts.Kind(name)
+}
+
+func (d *delegateOptions) Option(name, value string, hasValue bool) error {
+	opts, name := d.route(name)
+	return opts.Option(name, value, hasValue)
+}
+
+func (d *delegateOptions) OptionN(name string, values []string) error {
+	opts, name := d.route(name)
+	nopts, ok := opts.(OptionsWithOptionN)
+	if !ok {
+		panic("Kind() returns TakeTwoArgs or TakeNArgs but OptionN method is not implemented")
+	}
+	return nopts.OptionN(name, values)
+}
+
+func (d *delegateOptions) OptionCount(name string, count int) error {
+	opts, name := d.route(name)
+	if kopts, ok := opts.(OptionsWithCount); ok {
+		return kopts.OptionCount(name, count)
+	}
+	return opts.Option(name, strconv.Itoa(count), true)
+}
+
+func (d *delegateOptions) OptionProperty(name, key, value string, hasValue bool) error {
+	opts, name := d.route(name)
+	if popts, ok := opts.(OptionsWithProperty); ok {
+		return popts.OptionProperty(name, key, value, hasValue)
+	}
+	if hasValue {
+		key += "=" + value
+	}
+	return opts.Option(name, key, true)
+}
+
+func (d *delegateOptions) OptionToggle(name string, on bool, index int) error {
+	opts, name := d.route(name)
+	if topts, ok := opts.(OptionsWithToggle); ok {
+		return topts.OptionToggle(name, on, index)
+	}
+	return opts.Option(name, strconv.FormatBool(on), true)
+}
+
+func (d *delegateOptions) Secret(name string) bool {
+	opts, name := d.route(name)
+	return isSecret(opts, name)
+}
+
+func (d *delegateOptions) Deprecation(name string) string {
+	opts, name := d.route(name)
+	if dopts, ok := opts.(OptionsWithDeprecation); ok {
+		return dopts.Deprecation(name)
+	}
+	return ""
+}
+
+func (d *delegateOptions) OptionNames() []string {
+	var names []string
+	if nopts, ok := d.parent.(OptionsWithNames); ok {
+		names = append(names, nopts.OptionNames()...)
+	}
+	if nopts, ok := d.child.(OptionsWithNames); ok {
+		for _, name := range nopts.OptionNames() {
+			if rest, ok := strings.CutPrefix(name, "--"); ok && rest != "" {
+				names = append(names, d.prefix+rest)
+			}
+		}
+	}
+	return names
+}
+
+func (d *delegateOptions) Arg(index int, value string, afterDDash bool) error {
+	if aopts, ok := d.parent.(OptionsWithArg); ok {
+		return aopts.Arg(index, value, afterDDash)
+	}
+	return nil
+}
+
+func (d *delegateOptions) Args(before, after []string) error {
+	if aopts, ok := d.parent.(OptionsWithArgs); ok {
+		return aopts.Args(before, after)
+	}
+	return nil
+}
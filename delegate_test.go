@@ -0,0 +1,68 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestDelegate(t *testing.T) {
+	parent := &TestOptions{}
+	docker := &TestOptions{}
+	ssh := &TestOptions{}
+	opts := Delegate(Delegate(parent, "--docker-", docker), "--ssh-", ssh)
+	args, err := Parse(opts, []string{"-a", "--docker-required", "x", "--ssh-boolean", "--docker-set", "k", "v", "y", "--", "--docker-boolean"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"y", "--docker-boolean"})
+	CompareSlice(t, "parent", parent.OptionHistory, []OptionCall{{Name: "-a"}})
+	CompareSlice(t, "docker", docker.OptionHistory, []OptionCall{{Name: "--required", Value: "x", HasValue: true}})
+	CompareSliceF(t, "docker", docker.OptionNHistory, []OptionNCall{{Name: "--set", Values: []string{"k", "v"}}})
+	CompareSlice(t, "ssh", ssh.OptionHistory, []OptionCall{{Name: "--boolean"}})
+	CompareSlice(t, "before", parent.Before, []string{"y"})
+	CompareSlice(t, "after", parent.After, []string{"--docker-boolean"})
+
+	if _, err := Parse(opts, []string{"--docker-"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+	if _, err := Parse(opts, []string{"--docker-number", "NaN"}); err == nil || err.Error() != `option --docker-number: strconv.ParseInt: parsing "NaN": invalid syntax` {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	}
+}
+
+func TestDelegateSpec(t *testing.T) {
+	child := &Spec{
+		Name: "docker",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}, Kind: Counter},
+			{Names: []string{"--password"}, Kind: Required, Secret: true},
+			{Names: []string{"--host"}, Kind: Required, Deprecated: "use --context"},
+			{Names: []string{"--env"}, Kind: Property},
+		},
+	}
+	parent := &TestOptions{}
+	opts := Delegate(parent, "--docker-", child)
+	p := &Parser{Abbreviations: true}
+	args, err := p.Parse(opts, []string{"--docker-verbose", "--docker-verb", "--docker-verbose", "--docker-env", "A=1", "--docker-host", "h", "x"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	if n := child.Lookup("--verbose").Count(); n != 3 {
+		t.Errorf("--verbose: expected 3 occurrences, got %d", n)
+	}
+	CompareSlice(t, "--env", child.Lookup("--env").Values(), []string{"A=1"})
+	if warnings := p.Warnings(); len(warnings) != 1 || warnings[0].Error() != "option --docker-host is deprecated; use --context" {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	_, err = Parse(opts, []string{"--docker-password"})
+	if !isSecret(opts, "--docker-password") || isSecret(opts, "--docker-verbose") || isSecret(opts, "--password") {
+		t.Errorf("Secret() is not forwarded to the child")
+	}
+	if Code(err) != CodeMissingArg {
+		t.Errorf("Parse(): expected %s, but got %v", CodeMissingArg, err)
+	}
+}
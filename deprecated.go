@@ -0,0 +1,41 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+// Deprecated is a modifier of Kind for options that are still parsed but
+// should no longer be used. Each occurrence produces a warning with
+// CodeWarning, "option --foo is deprecated", followed by the message of the
+// Deprecation method if implemented.
+const Deprecated Kind = 1 << 12
+
+// OptionsWithWarning is an interface that adds the Warning method to Options.
+//
+// Warning is called for each warning of the parse, such as those returned by
+// the handlers with Warnf and those of Deprecated options, so that they can
+// be reported even by the package-level parse functions.
+type OptionsWithWarning interface {
+	Options
+
+	Warning(warning error)
+}
+
+// OptionsWithDeprecation is an interface that adds the Deprecation method to
+// Options.
+//
+// Deprecation returns the message appended to the warning of a Deprecated
+// option, e.g. "use --bar", or "" if none.
+type OptionsWithDeprecation interface {
+	Options
+
+	Deprecation(name string) string
+}
+
+func deprecationWarning(opts Options, name string) error {
+	if dopts, ok := opts.(OptionsWithDeprecation); ok {
+		if msg := dopts.Deprecation(name); msg != "" {
+			return errorf(CodeWarning, name, "option %s is deprecated; %s", name, msg)
+		}
+	}
+	return errorf(CodeWarning, name, "option %s is deprecated", name)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+type deprecatedOptions struct {
+	TestOptions
+	Warnings []string
+}
+
+func (opts *deprecatedOptions) Kind(name string) Kind {
+	switch name {
+	case "--old":
+		return Boolean | Deprecated
+	case "--legacy":
+		return Required | Deprecated
+	default:
+		return opts.TestOptions.Kind(name)
+	}
+}
+
+func (opts *deprecatedOptions) Deprecation(name string) string {
+	if name == "--old" {
+		return "use -a"
+	}
+	return ""
+}
+
+func (opts *deprecatedOptions) Warning(warning error) {
+	opts.Warnings = append(opts.Warnings, warning.Error())
+}
+
+func TestDeprecated(t *testing.T) {
+	opts := &deprecatedOptions{}
+	p := &Parser{}
+	if _, err := p.Parse(opts, []string{"--old", "-a", "--legacy=x", "--old"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "options", opts.OptionHistory, []OptionCall{
+		{Name: "--old"},
+		{Name: "-a"},
+		{Name: "--legacy", Value: "x", HasValue: true},
+		{Name: "--old"},
+	})
+	CompareSlice(t, "warnings", opts.Warnings, []string{
+		"option --old is deprecated; use -a",
+		"option --legacy is deprecated",
+		"option --old is deprecated; use -a",
+	})
+	if warnings := p.Warnings(); len(warnings) != 3 || Code(warnings[0]) != CodeWarning {
+		t.Errorf("Warnings() = %v", warnings)
+	}
+
+	opts = &deprecatedOptions{}
+	if _, err := Parse(opts, []string{"--legacy"}); Code(err) != CodeMissingArg {
+		t.Errorf("Parse(): expected %s, but got %v", CodeMissingArg, err)
+	}
+	CompareSlice(t, "warnings", opts.Warnings, nil)
+}
+
+func TestSpecDeprecated(t *testing.T) {
+	spec := &Spec{
+		Name: "example",
+		Options: []*OptionSpec{
+			{Names: []string{"--color"}},
+			{Names: []string{"--colour"}, Deprecated: "use --color"},
+		},
+	}
+	if kind := spec.Kind("--colour"); kind != Boolean|Deprecated {
+		t.Errorf("Kind() = %v, want %v", kind, Boolean|Deprecated)
+	}
+	p := &Parser{}
+	if _, err := p.Parse(spec, []string{"--colour"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	warnings := p.Warnings()
+	if len(warnings) != 1 || warnings[0].Error() != "option --colour is deprecated; use --color" {
+		t.Errorf("Warnings() = %v", warnings)
+	}
+	if spec.Options[1].Count() != 1 {
+		t.Errorf("--colour: Count() = %d, want 1", spec.Options[1].Count())
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode is a stable machine-readable identifier of the kind of a
+// command-line error, suitable for tests, telemetry and translations.
+type ErrorCode string
+
+const (
+	// CodeOther is the code of errors created by Errorf.
+	CodeOther ErrorCode = "E_OTHER"
+
+	// CodeHelp is the code of ErrHelp.
+	CodeHelp ErrorCode = "E_HELP"
+
+	// CodeVersion is the code of ErrVersion.
+	CodeVersion ErrorCode = "E_VERSION"
+
+	// CodeUnknownOption is the code of errors reporting an unknown option.
+	CodeUnknownOption ErrorCode = "E_UNKNOWN_OPTION"
+
+	// CodeMissingArg is the code of errors reporting an option given fewer
+	// arguments than it requires.
+	CodeMissingArg ErrorCode = "E_MISSING_ARG"
+
+	// CodeUnexpectedArg is the code of errors reporting an argument given to
+	// an option in a form it does not accept, e.g. --flag=VALUE for a
+	// Boolean option.
+	CodeUnexpectedArg ErrorCode = "E_UNEXPECTED_ARG"
+
+	// CodeInvalidOption is the code of errors reporting a malformed option.
+	CodeInvalidOption ErrorCode = "E_INVALID_OPTION"
+
+	// CodeInvalidValue is the code of errors returned by the Option and
+	// OptionN methods, wrapped by the parser.
+	CodeInvalidValue ErrorCode = "E_INVALID_VALUE"
+
+	// CodeMissingOption is the code of errors reporting a missing required
+	// option.
+	CodeMissingOption ErrorCode = "E_MISSING_OPTION"
+
+	// CodeRepeatedOption is the code of errors reporting an option specified
+	// more times than permitted by OptionSpec.Max.
+	CodeRepeatedOption ErrorCode = "E_REPEATED_OPTION"
+
+	// CodeAmbiguousOption is the code of errors reporting an abbreviated long
+	// option matching several options.
+	CodeAmbiguousOption ErrorCode = "E_AMBIGUOUS_OPTION"
+
+	// CodeUnknownCommand is the code of errors reporting an unknown
+	// subcommand.
+	CodeUnknownCommand ErrorCode = "E_UNKNOWN_COMMAND"
+
+	// CodeNoSubcommand is the code of ErrNoSubcommand.
+	CodeNoSubcommand ErrorCode = "E_NO_SUBCOMMAND"
+
+	// CodeWarning is the code of warnings created by Warnf.
+	CodeWarning ErrorCode = "W_WARNING"
+)
+
+// Error is a command-line error. It satisfies errors.Is(err, ErrCmdline).
+// The message is formatted lazily, so that callers that only inspect errors
+// with errors.Is or the Code pay no formatting cost.
+type Error struct {
+	// Code identifies the kind of the error.
+	Code ErrorCode
+
+	// Option is the name of the option the error is about, or "".
+	Option string
+
+	// Prog is the name of the program, which prefixes the message if not
+	// empty. It is set by ParseArgv.
+	Prog string
+
+	format string
+	args   []any
+	err    error
+}
+
+func (e *Error) Error() string {
+	if e.Prog != "" {
+		return e.Prog + ": " + fmt.Errorf(e.format, e.args...).Error()
+	}
+	return fmt.Errorf(e.format, e.args...).Error()
+}
+
+func (e *Error) Is(target error) bool { return target == ErrCmdline }
+
+// Unwrap returns the error operand of the %w verb, like the error returned by
+// fmt.Errorf. It returns nil if the format contains zero or multiple %w verbs.
+func (e *Error) Unwrap() error { return e.err }
+
+// Errorf returns an *Error with CodeOther that formats as
+// fmt.Errorf(format, a...). The message is formatted lazily, when the Error
+// method is called, so the arguments must not be modified afterwards.
+func Errorf(format string, a ...any) error {
+	return &Error{Code: CodeOther, format: format, args: a, err: wrappedError(format, a)}
+}
+
+// Warnf returns a warning, an *Error with CodeWarning. If the Option,
+// OptionN, Arg or Args method returns a warning, the parse continues instead
+// of failing. The warnings are available through Parser.Warnings and
+// ParseResult.Warnings; the package-level parse functions discard them. Like
+// Errorf, the message is formatted lazily.
+func Warnf(format string, a ...any) error {
+	return &Error{Code: CodeWarning, format: format, args: a, err: wrappedError(format, a)}
+}
+
+// wrappedError returns the operand of the %w verb in format, or nil if there
+// are zero or multiple %w verbs. Only the error arguments are passed to
+// fmt.Errorf, so that the others are still formatted lazily.
+func wrappedError(format string, a []any) error {
+	var errs []any
+	for i, arg := range a {
+		if err, ok := arg.(error); ok {
+			if errs == nil {
+				errs = make([]any, len(a))
+			}
+			errs[i] = err
+		}
+	}
+	if errs == nil {
+		return nil
+	}
+	return errors.Unwrap(fmt.Errorf(format, errs...))
+}
+
+// errorf returns an *Error with code. If format ends with %w, the last
+// argument is the wrapped error.
+func errorf(code ErrorCode, option, format string, a ...any) error {
+	e := &Error{Code: code, Option: option, format: format, args: a}
+	if strings.HasSuffix(format, "%w") {
+		e.err, _ = a[len(a)-1].(error)
+	}
+	return e
+}
+
+// wrapOptionError wraps an error returned by the Option or OptionN method.
+// Errors carrying a specific code, such as ErrHelp, keep it.
+func wrapOptionError(name string, err error) error {
+	code := CodeInvalidValue
+	var e *Error
+	if errors.As(err, &e) && e.Code != CodeOther {
+		code = e.Code
+	}
+	return errorf(code, name, "option %s: %w", name, err)
+}
+
+// Code returns the code of the first *Error in the chain of err, or "" if
+// there is none.
+func Code(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	helpOptions := &TestOptions{}
+	tests := []struct {
+		args   []string
+		code   ErrorCode
+		option string
+	}{
+		{[]string{"--unknown"}, CodeUnknownOption, "--unknown"},
+		{[]string{"-x"}, CodeUnknownOption, "-x"},
+		{[]string{"-r"}, CodeMissingArg, "-r"},
+		{[]string{"--set", "k"}, CodeMissingArg, "--set"},
+		{[]string{"--boolean=x"}, CodeUnexpectedArg, "--boolean"},
+		{[]string{"--set=k", "v"}, CodeUnexpectedArg, "--set"},
+		{[]string{"-a-"}, CodeInvalidOption, "-a"},
+		{[]string{"--number", "x"}, CodeInvalidValue, "--number"},
+		{[]string{"--help"}, CodeHelp, "--help"},
+		{[]string{"--version"}, CodeVersion, "--version"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(helpOptions, tt.args)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%q: expected *Error, but got %v", tt.args, err)
+			continue
+		}
+		if e.Code != tt.code || e.Option != tt.option {
+			t.Errorf("%q: got (%s, %q), want (%s, %q)", tt.args, e.Code, e.Option, tt.code, tt.option)
+		}
+		if Code(err) != tt.code {
+			t.Errorf("%q: Code() = %s, want %s", tt.args, Code(err), tt.code)
+		}
+	}
+
+	if _, err := ParseS(&TestOptions{}, nil); Code(err) != CodeNoSubcommand {
+		t.Errorf("ParseS(): Code() = %s, want %s", Code(err), CodeNoSubcommand)
+	}
+	if code := Code(Errorf("x")); code != CodeOther {
+		t.Errorf("Code(Errorf()) = %s, want %s", code, CodeOther)
+	}
+	if code := Code(errors.New("x")); code != "" {
+		t.Errorf("Code(errors.New()) = %s, want empty", code)
+	}
+}
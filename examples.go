@@ -0,0 +1,171 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+	"strings"
+)
+
+// Example is an example invocation generated by GenerateExamples.
+type Example struct {
+	// Args is the argument list, not including the command name, which can
+	// be given to Parse or Validate of the root spec.
+	Args []string
+
+	// Line is the command line including the command name, quoted for the
+	// shell where necessary.
+	Line string
+}
+
+// GenerateExamples returns example invocations of each visible command that
+// has no subcommands: one with only the required options and arguments, and
+// one that additionally uses every visible option of the command. Values are
+// the first of Choices, or the Metavar. The minimal example also uses the
+// first option of each RequireOneOf group, and both examples use the options
+// required by the others. The examples follow the grammar and the constraints
+// of the spec, so they can be checked with Validate or Parse to smoke-test
+// documentation.
+func (s *Spec) GenerateExamples() []Example {
+	s.init()
+	var examples []Example
+	s.generateExamples(nil, &examples)
+	return examples
+}
+
+func (s *Spec) generateExamples(path []string, examples *[]Example) {
+	if s.parent != nil {
+		path = append(path, s.Name)
+	}
+	if len(s.Commands) > 0 {
+		for _, cmd := range s.Commands {
+			if !cmd.Hidden {
+				cmd.generateExamples(path, examples)
+			}
+		}
+		return
+	}
+
+	var required []*OptionSpec
+	for c := s; c != nil; c = c.parent {
+		for _, o := range c.Options {
+			if o.Required {
+				required = append(required, o)
+			}
+		}
+		for _, group := range c.RequireOneOf {
+			if !slices.ContainsFunc(group, func(name string) bool {
+				return slices.Contains(required, c.Lookup(name))
+			}) {
+				if o := c.Lookup(group[0]); o != nil {
+					required = append(required, o)
+				}
+			}
+		}
+	}
+	required = s.withRequires(required)
+	var positional []string
+	for _, a := range s.Positional {
+		if len(a.Choices) > 0 {
+			positional = append(positional, a.Choices[0])
+		} else {
+			positional = append(positional, a.Name)
+		}
+	}
+	*examples = append(*examples, s.newExample(path, required, positional))
+
+	all := slices.Clone(required)
+	for _, o := range s.Options {
+		if !o.Hidden && !slices.Contains(all, o) {
+			all = append(all, o)
+		}
+	}
+	if all = s.withRequires(all); len(all) > len(required) {
+		*examples = append(*examples, s.newExample(path, all, positional))
+	}
+}
+
+// withRequires appends to opts the options they require, recursively.
+func (s *Spec) withRequires(opts []*OptionSpec) []*OptionSpec {
+	for i := 0; i < len(opts); i++ {
+		for _, name := range opts[i].Requires {
+			if o := s.Lookup(name); o != nil && !slices.Contains(opts, o) {
+				opts = append(opts, o)
+			}
+		}
+	}
+	return opts
+}
+
+// newExample returns the example using opts and positional. A Rest option
+// takes the remaining arguments, so it comes last, and only one can be used.
+func (s *Spec) newExample(path []string, opts []*OptionSpec, positional []string) Example {
+	args := slices.Clone(path)
+	var rest []string
+	for _, o := range opts {
+		switch {
+		case o.kind().Base() != Rest:
+			args = append(args, exampleOption(o)...)
+		case rest == nil:
+			rest = exampleOption(o)
+		}
+	}
+	args = slices.Concat(args, positional, rest)
+	var sb strings.Builder
+	sb.WriteString(s.root().Name)
+	for _, arg := range args {
+		sb.WriteString(" " + exampleQuote(arg))
+	}
+	return Example{Args: args, Line: sb.String()}
+}
+
+func exampleOption(o *OptionSpec) []string {
+	name := o.Long()
+	if name == "" {
+		name = o.Short()
+	}
+	value := o.Metavar
+	if value == "" {
+		value = "VALUE"
+	}
+	if len(o.Choices) > 0 {
+		value = o.Choices[0]
+	}
+	switch kind := o.kind(); kind.Base() {
+	case Required:
+		return []string{name, value}
+	case Property:
+		if o.Metavar == "" && len(o.Choices) == 0 {
+			value = "KEY=VALUE"
+		}
+		return []string{name, value}
+	case Optional:
+		if strings.HasPrefix(name, "--") {
+			return []string{name + "=" + value}
+		}
+		return []string{name + value}
+	case TakeTwoArgs:
+		example := []string{name}
+		for range kind.NArgs() {
+			example = append(example, value)
+		}
+		return example
+	case Rest:
+		return []string{name, value}
+	case Terminated:
+		if terms := kind.terminators(); terms != nil {
+			return []string{name, value, terms[0]}
+		}
+		return []string{name}
+	default:
+		return []string{name}
+	}
+}
+
+func exampleQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=.,/:@%+") == "" {
+		return s
+	}
+	return ShellQuote(s)
+}
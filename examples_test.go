@@ -0,0 +1,91 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateExamples(t *testing.T) {
+	spec := newTestSpec()
+	spec.Options[1].Required = true
+	spec.Commands = append(spec.Commands, &Spec{Name: "secret", Hidden: true})
+
+	var lines []string
+	for _, example := range spec.GenerateExamples() {
+		lines = append(lines, example.Line)
+		if err := spec.Validate(example.Args); err != nil {
+			t.Errorf("%s: %v", example.Line, err)
+		}
+		if _, _, err := spec.Parse(example.Args); err != nil {
+			t.Errorf("%s: %v", example.Line, err)
+		}
+		spec.Reset()
+	}
+	CompareSlice(t, "examples", lines, []string{
+		"example run --file FILE COMMAND ARGS",
+		"example run --file FILE --dry-run COMMAND ARGS",
+	})
+
+	spec = &Spec{
+		Name: "xargs",
+		Options: []*OptionSpec{
+			{Names: []string{"-e", "--exec"}, Kind: Rest, Metavar: "CMD"},
+			{Names: []string{"-v"}},
+		},
+		Positional: []*ArgSpec{{Name: "FILE"}},
+	}
+	lines = nil
+	for _, example := range spec.GenerateExamples() {
+		lines = append(lines, example.Line)
+		if _, args, err := spec.Parse(example.Args); err != nil || len(args) != 1 {
+			t.Errorf("%s: got %q, %v", example.Line, args, err)
+		}
+		spec.Reset()
+	}
+	CompareSlice(t, "examples", lines, []string{
+		"xargs FILE",
+		"xargs -v FILE --exec CMD",
+	})
+
+	spec = &Spec{
+		Name: "fetch",
+		Options: []*OptionSpec{
+			{Names: []string{"-q"}},
+			{Names: []string{"--user"}, Kind: Required, Metavar: "USER", Requires: []string{"--password"}},
+			{Names: []string{"--password"}, Kind: Required, Metavar: "PASS"},
+			{Names: []string{"--url"}, Kind: Required, Metavar: "URL", Requires: []string{"--user"}},
+			{Names: []string{"--file"}, Kind: Required, Metavar: "FILE"},
+		},
+		RequireOneOf: [][]string{{"--url", "--file"}},
+	}
+	lines = nil
+	for _, example := range spec.GenerateExamples() {
+		lines = append(lines, example.Line)
+		if err := spec.Validate(example.Args); err != nil {
+			t.Errorf("%s: %v", example.Line, err)
+		}
+		if _, _, err := spec.Parse(example.Args); err != nil {
+			t.Errorf("%s: %v", example.Line, err)
+		}
+		spec.Reset()
+	}
+	CompareSlice(t, "examples", lines, []string{
+		"fetch --url URL --user USER --password PASS",
+		"fetch --url URL --user USER --password PASS -q --file FILE",
+	})
+}
+
+func TestHelpExamples(t *testing.T) {
+	spec := &Spec{Name: "example", Examples: []string{"example --all", "example -n 'a b'"}}
+	var sb strings.Builder
+	if err := spec.WriteHelp(&sb, StandardHelp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Usage: example [OPTIONS]\n\nExamples:\n  example --all\n  example -n 'a b'\n"
+	if sb.String() != expected {
+		t.Errorf("unexpected help:\n%s", sb.String())
+	}
+}
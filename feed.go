@@ -0,0 +1,151 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"slices"
+)
+
+// Feeder parses a command line given incrementally, e.g. in fragments
+// received over RPC. The methods of opts are called as soon as the tokens
+// are complete: an option whose value has not arrived yet waits for the next
+// call to Feed, and a Rest option for Close. Interleaving of options and
+// non-options is allowed, as with Parse.
+//
+// The PreParse method is not called. The counts of Counter options and the
+// Args, ArgsIndexed and PostParse methods are delivered by Close.
+type Feeder struct {
+	p          Parser
+	h          handlers
+	t          Tokenizer
+	st         optionState
+	positional []string
+	indexed    []IndexedArg
+	nbefore    int
+	ddash      bool
+	closed     bool
+	err        error
+}
+
+// NewFeeder returns a Feeder delivering the options to opts.
+func NewFeeder(opts Options) *Feeder {
+	return &Feeder{h: newHandlers(opts), t: Tokenizer{opts: opts}}
+}
+
+// Feed appends args to the command line and processes the tokens completed
+// by them. Once it fails, it keeps returning the same error.
+func (f *Feeder) Feed(args ...string) error {
+	if f.closed {
+		return errors.New("options: Feed called after Close")
+	}
+	if f.err == nil {
+		f.t.args = append(f.t.args, args...)
+		f.err = f.run(false)
+	}
+	return f.err
+}
+
+// Close ends the command line, processing the pending tokens, and returns
+// the positional arguments.
+func (f *Feeder) Close() ([]string, error) {
+	if f.closed {
+		return nil, errors.New("options: Close called twice")
+	}
+	f.closed = true
+	if f.err == nil {
+		f.err = f.run(true)
+	}
+	if f.err == nil {
+		f.err = f.finish()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.positional, nil
+}
+
+// Warnings returns the warnings reported by the handlers so far.
+func (f *Feeder) Warnings() []error {
+	return f.p.warnings
+}
+
+// run processes the complete tokens. Unless final, a token lacking arguments
+// is left for later.
+func (f *Feeder) run(final bool) error {
+	for {
+		saved := f.t
+		if !f.t.Next() {
+			if f.t.err != nil && !final && Code(f.t.err) == CodeMissingArg {
+				f.t = saved
+				return nil
+			}
+			return f.t.err
+		}
+		tok := &f.t.tok
+		if !final && tok.Kind == OptionToken && f.t.kind.Base() == Rest {
+			f.t = saved
+			return nil
+		}
+		var err error
+		switch tok.Kind {
+		case OptionToken:
+			err = f.p.optionToken(&f.h, &f.t, &f.st, f.t.args)
+		case DDashToken:
+			f.ddash = true
+			f.nbefore = len(f.positional)
+		case PositionalToken:
+			if f.h.aopts != nil {
+				err = f.h.aopts.Arg(len(f.positional), tok.Value, tok.AfterDDash)
+			}
+			f.positional = append(f.positional, tok.Value)
+			if f.h.iopts != nil {
+				f.indexed = append(f.indexed, IndexedArg{tok.Index, tok.Value})
+			}
+		}
+		if err != nil && !f.p.warn(&f.h, err) {
+			return err
+		}
+		if err == nil && tok.Kind == OptionToken && f.t.kind&Deprecated != 0 {
+			f.p.warn(&f.h, deprecationWarning(f.h.opts, tok.Name))
+		}
+	}
+}
+
+// finish delivers the counts and the positional arguments.
+func (f *Feeder) finish() error {
+	h := &f.h
+	for _, c := range f.st.counters {
+		if err := h.count(c.name, c.n); err != nil && !f.p.warn(h, err) {
+			return err
+		}
+	}
+	if !f.ddash {
+		f.nbefore = len(f.positional)
+	}
+	if h.sopts != nil {
+		before, after := f.positional[:f.nbefore:f.nbefore], slices.Clip(f.positional[f.nbefore:])
+		if !f.ddash {
+			after = nil
+		}
+		if err := h.sopts.Args(before, after); err != nil && !f.p.warn(h, err) {
+			return err
+		}
+	}
+	if h.iopts != nil {
+		before, after := f.indexed[:f.nbefore:f.nbefore], slices.Clip(f.indexed[f.nbefore:])
+		if !f.ddash {
+			after = nil
+		}
+		if err := h.iopts.ArgsIndexed(before, after); err != nil && !f.p.warn(h, err) {
+			return err
+		}
+	}
+	if h.post != nil {
+		if err := h.post.PostParse(slices.Clip(f.positional)); err != nil && !f.p.warn(h, err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestFeeder(t *testing.T) {
+	opts := &TestOptions{}
+	f := NewFeeder(opts)
+	for _, chunk := range [][]string{{"x", "-a"}, {"-br"}, {"value", "--set", "k"}, {"v", "--"}, {"-c"}} {
+		if err := f.Feed(chunk...); err != nil {
+			t.Fatalf("Feed(%q): unexpected error: %v", chunk, err)
+		}
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "-a"}, {Name: "-b"}, {Name: "-r", Value: "value", HasValue: true},
+	})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{{Name: "--set", Values: []string{"k", "v"}}})
+	CompareSlice(t, "ArgHistory", opts.ArgHistory, []ArgCall{
+		{Index: 0, Value: "x"}, {Index: 1, Value: "-c", AfterDDash: true},
+	})
+	args, err := f.Close()
+	if err != nil {
+		t.Fatalf("Close(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "-c"})
+	CompareSlice(t, "Before", opts.Before, []string{"x"})
+	CompareSlice(t, "After", opts.After, []string{"-c"})
+
+	if err := f.Feed("-a"); err == nil {
+		t.Errorf("Feed(): expected error after Close")
+	}
+}
+
+func TestFeederErrors(t *testing.T) {
+	f := NewFeeder(&TestOptions{})
+	if err := f.Feed("-a", "-r"); err != nil {
+		t.Fatalf("Feed(): unexpected error: %v", err)
+	}
+	if _, err := f.Close(); Code(err) != CodeMissingArg {
+		t.Errorf("Close(): expected %s, but got %v", CodeMissingArg, err)
+	}
+
+	f = NewFeeder(&TestOptions{})
+	if err := f.Feed("--unknown"); Code(err) != CodeUnknownOption {
+		t.Errorf("Feed(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+	if err := f.Feed("-a"); Code(err) != CodeUnknownOption {
+		t.Errorf("Feed(): expected the same error, but got %v", err)
+	}
+}
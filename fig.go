@@ -0,0 +1,134 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+type figSpec struct {
+	Name        any          `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Hidden      bool         `json:"hidden,omitempty"`
+	Subcommands []*figSpec   `json:"subcommands,omitempty"`
+	Options     []*figOption `json:"options,omitempty"`
+	Args        []*figArg    `json:"args,omitempty"`
+}
+
+type figOption struct {
+	Name         []string  `json:"name"`
+	Description  string    `json:"description,omitempty"`
+	IsRequired   bool      `json:"isRequired,omitempty"`
+	IsPersistent bool      `json:"isPersistent,omitempty"`
+	Hidden       bool      `json:"hidden,omitempty"`
+	Args         []*figArg `json:"args,omitempty"`
+}
+
+type figArg struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	IsOptional  bool     `json:"isOptional,omitempty"`
+	IsVariadic  bool     `json:"isVariadic,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Template    string   `json:"template,omitempty"`
+	Default     string   `json:"default,omitempty"`
+}
+
+// WriteFig writes the spec as a Fig completion spec in JSON to w.
+func (s *Spec) WriteFig(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(figCommand(s))
+}
+
+// WriteFigTS writes the spec as a Fig completion spec TypeScript module to w.
+func (s *Spec) WriteFigTS(w io.Writer) error {
+	data, err := json.MarshalIndent(figCommand(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "const completionSpec: Fig.Spec = "); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, ";\n\nexport default completionSpec;\n")
+	return err
+}
+
+func figCommand(s *Spec) *figSpec {
+	fs := &figSpec{
+		Name:        s.Name,
+		Description: s.Summary,
+		Hidden:      s.Hidden,
+	}
+	if len(s.Aliases) > 0 {
+		fs.Name = append([]string{s.Name}, s.Aliases...)
+	}
+	for _, o := range s.Options {
+		fo := &figOption{
+			Name:         o.Names,
+			Description:  o.Help,
+			IsRequired:   o.Required,
+			IsPersistent: len(s.Commands) > 0,
+			Hidden:       o.Hidden,
+		}
+		switch kind := o.kind(); kind.Base() {
+		case Boolean, Counter, Toggle:
+		case TakeTwoArgs:
+			for i := range kind.NArgs() {
+				fo.Args = append(fo.Args, figValue(o, "VALUE"+strconv.Itoa(i+1)))
+			}
+		case Rest, Terminated:
+			arg := figValue(o, "VALUE")
+			arg.IsOptional = true
+			arg.IsVariadic = true
+			fo.Args = []*figArg{arg}
+		default:
+			arg := figValue(o, "VALUE")
+			arg.IsOptional = o.kind().Base() == Optional
+			fo.Args = []*figArg{arg}
+		}
+		fs.Options = append(fs.Options, fo)
+	}
+	for _, a := range s.Positional {
+		fs.Args = append(fs.Args, &figArg{
+			Name:        a.Name,
+			Description: a.Help,
+			IsVariadic:  a.Variadic,
+			Suggestions: a.Choices,
+			Template:    figTemplate(a.Complete),
+		})
+	}
+	for _, cmd := range s.Commands {
+		fs.Subcommands = append(fs.Subcommands, figCommand(cmd))
+	}
+	return fs
+}
+
+func figValue(o *OptionSpec, name string) *figArg {
+	if o.Metavar != "" {
+		name = o.Metavar
+	}
+	return &figArg{
+		Name:        name,
+		Suggestions: o.Choices,
+		Template:    figTemplate(o.completion()),
+		Default:     o.Default,
+	}
+}
+
+func figTemplate(c Completion) string {
+	switch c {
+	case CompleteFiles:
+		return "filepaths"
+	case CompleteDirs:
+		return "folders"
+	default:
+		return ""
+	}
+}
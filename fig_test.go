@@ -0,0 +1,105 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteFig(t *testing.T) {
+	var sb strings.Builder
+	if err := newTestSpec().WriteFig(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+  "name": "example",
+  "description": "An example command",
+  "subcommands": [
+    {
+      "name": [
+        "run",
+        "r"
+      ],
+      "description": "Run a command",
+      "options": [
+        {
+          "name": [
+            "-n",
+            "--dry-run"
+          ],
+          "description": "do not run"
+        }
+      ],
+      "args": [
+        {
+          "name": "COMMAND",
+          "description": "command to run"
+        },
+        {
+          "name": "ARGS",
+          "description": "arguments",
+          "isVariadic": true,
+          "template": "filepaths"
+        }
+      ]
+    }
+  ],
+  "options": [
+    {
+      "name": [
+        "-v",
+        "--verbose"
+      ],
+      "description": "verbose output",
+      "isPersistent": true
+    },
+    {
+      "name": [
+        "-f",
+        "--file"
+      ],
+      "description": "input file",
+      "isPersistent": true,
+      "args": [
+        {
+          "name": "FILE",
+          "template": "filepaths"
+        }
+      ]
+    },
+    {
+      "name": [
+        "--color"
+      ],
+      "description": "colorize output",
+      "isPersistent": true,
+      "args": [
+        {
+          "name": "WHEN",
+          "isOptional": true,
+          "suggestions": [
+            "always",
+            "never",
+            "auto"
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	if actual := sb.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	sb.Reset()
+	if err := (&Spec{Name: "x"}).WriteFigTS(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = "const completionSpec: Fig.Spec = {\n  \"name\": \"x\"\n};\n\nexport default completionSpec;\n"
+	if actual := sb.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
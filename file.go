@@ -0,0 +1,53 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// File and NewFile are modifiers of Kind for options whose values are paths.
+// Before the value is passed to Option or OptionN, File checks that it names
+// an existing readable file, and NewFile that it names either such a file or
+// a path that can be created, i.e. whose directory exists. The value "-",
+// which conventionally stands for the standard input or output, is always
+// accepted. Spec completes the values of these options as files.
+const (
+	File    Kind = 1 << 13
+	NewFile Kind = 1 << 14
+)
+
+func checkFile(name, path string, kind Kind) error {
+	if path == "-" {
+		return nil
+	}
+	if path == "" {
+		return errorf(CodeInvalidValue, name, "option %s: empty file name", name)
+	}
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) && kind&NewFile != 0 {
+		dir := filepath.Dir(path)
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return errorf(CodeInvalidValue, name, "option %s: no such directory: %s", name, dir)
+		}
+		return nil
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return errorf(CodeInvalidValue, name, "option %s: no such file: %s", name, path)
+	case err != nil:
+		return errorf(CodeInvalidValue, name, "option %s: %w", name, err)
+	case fi.IsDir():
+		return errorf(CodeInvalidValue, name, "option %s: is a directory: %s", name, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return errorf(CodeInvalidValue, name, "option %s: %w", name, err)
+	}
+	f.Close()
+	return nil
+}
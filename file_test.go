@@ -0,0 +1,78 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fileOptions struct {
+	TestOptions
+}
+
+func (opts *fileOptions) Kind(name string) Kind {
+	switch name {
+	case "-i", "--input":
+		return Required | File
+	case "--output":
+		return Required | NewFile
+	case "--pair":
+		return TakeTwoArgs | File
+	default:
+		return opts.TestOptions.Kind(name)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.WriteFile(input, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "output")
+
+	opts := &fileOptions{}
+	if _, err := Parse(opts, []string{"-i", input, "--input=-", "--output", output, "--output", input, "--pair", input, input}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "options", opts.OptionHistory, []OptionCall{
+		{Name: "-i", Value: input, HasValue: true},
+		{Name: "--input", Value: "-", HasValue: true},
+		{Name: "--output", Value: output, HasValue: true},
+		{Name: "--output", Value: input, HasValue: true},
+	})
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--input", output}, "option --input: no such file: " + output},
+		{[]string{"--input", dir}, "option --input: is a directory: " + dir},
+		{[]string{"--input="}, "option --input: empty file name"},
+		{[]string{"--output", filepath.Join(output, "x")}, "option --output: no such directory: " + output},
+		{[]string{"--pair", input, output}, "option --pair: no such file: " + output},
+	} {
+		opts := &fileOptions{}
+		_, err := Parse(opts, tt.args)
+		if Code(err) != CodeInvalidValue || err.Error() != tt.want {
+			t.Errorf("Parse(%q): expected %q, but got %v", tt.args, tt.want, err)
+		}
+		if len(opts.OptionHistory) != 0 || len(opts.OptionNHistory) != 0 {
+			t.Errorf("Parse(%q): handler must not be called", tt.args)
+		}
+	}
+}
+
+func TestSpecFileCompletion(t *testing.T) {
+	o := &OptionSpec{Names: []string{"--config"}, Kind: Required | File}
+	if c := o.completion(); c != CompleteFiles {
+		t.Errorf("completion() = %v, want %v", c, CompleteFiles)
+	}
+	o.Complete = CompleteDirs
+	if c := o.completion(); c != CompleteDirs {
+		t.Errorf("completion() = %v, want %v", c, CompleteDirs)
+	}
+}
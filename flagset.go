@@ -0,0 +1,146 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"flag"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+type flagSetOptions struct {
+	fs *flag.FlagSet
+}
+
+// FromFlagSet returns an Options that parses the flags registered in fs.
+//
+// A flag with a single-character name x is accepted as -x, and a flag with
+// a longer name is accepted as --name. In the LongOnly mode of Parser, every
+// flag is accepted with either one or two dashes, like the flag package
+// does. Boolean flags are Boolean and the other flags are Required. If fs
+// does not define h or help, -h and --help return ErrHelp. After parsing,
+// fs.Args returns the positional arguments.
+func FromFlagSet(fs *flag.FlagSet) Options {
+	return &flagSetOptions{fs}
+}
+
+func (opts *flagSetOptions) lookup(name string) *flag.Flag {
+	switch {
+	case strings.HasPrefix(name, "--") && len(name) > 3:
+		return opts.fs.Lookup(name[2:])
+	case !strings.HasPrefix(name, "--") && len(name) == 2:
+		return opts.fs.Lookup(name[1:])
+	default:
+		return nil
+	}
+}
+
+func (opts *flagSetOptions) isHelp(name string) bool {
+	return (name == "-h" || name == "--help") && opts.lookup(name) == nil
+}
+
+func flagKind(f *flag.Flag) Kind {
+	if bf, ok := f.Value.(boolFlag); ok && bf.IsBoolFlag() {
+		return Boolean
+	}
+	return Required
+}
+
+func (opts *flagSetOptions) Kind(name string) Kind {
+	f := opts.lookup(name)
+	switch {
+	case f != nil:
+		return flagKind(f)
+	case opts.isHelp(name):
+		return Boolean
+	default:
+		return Unknown
+	}
+}
+
+func (opts *flagSetOptions) Option(name, value string, hasValue bool) error {
+	f := opts.lookup(name)
+	switch {
+	case f != nil:
+		if !hasValue {
+			value = "true"
+		}
+		return opts.fs.Set(f.Name, value)
+	case opts.isHelp(name):
+		return ErrHelp
+	default:
+		return ErrUnknown
+	}
+}
+
+func (opts *flagSetOptions) Args(before, after []string) error {
+	return opts.fs.Parse(slices.Concat([]string{"--"}, before, after))
+}
+
+type specValue struct {
+	s    *Spec
+	o    *OptionSpec
+	name string
+}
+
+func (v *specValue) String() string {
+	if v.o == nil {
+		return ""
+	}
+	if v.o.flag() {
+		return strconv.FormatBool(v.o.count > 0)
+	}
+	value, _ := v.o.Value()
+	return value
+}
+
+func (v *specValue) Set(value string) error {
+	if v.s.root().frozen {
+		return ErrFrozen
+	}
+	if !v.o.flag() {
+		return v.o.set(v.name, []string{value})
+	}
+	return v.o.setBool(v.name, value)
+}
+
+func (v *specValue) IsBoolFlag() bool {
+	return v.o.flag()
+}
+
+// FlagSet returns a *flag.FlagSet view of the spec. Each name of the options
+// is registered as a flag without dashes, whose value reads and writes the
+// option. Flags registered to the FlagSet by other code are also accepted
+// when parsing with the spec, as described in FromFlagSet.
+//
+// FlagSet returns the same *flag.FlagSet on every call.
+func (s *Spec) FlagSet() *flag.FlagSet {
+	if s.flagSet != nil {
+		return s.flagSet
+	}
+	s.flagSet = flag.NewFlagSet(s.Name, flag.ContinueOnError)
+	for _, o := range s.Options {
+		for _, name := range o.Names {
+			s.flagSet.Var(&specValue{s, o, name}, strings.TrimLeft(name, "-"), o.Help)
+		}
+	}
+	return s.flagSet
+}
+
+func (s *Spec) lookupFlag(name string) *flag.Flag {
+	s.init()
+	for c := s; c != nil; c = c.parent {
+		if c.flagSet != nil {
+			if f := (&flagSetOptions{c.flagSet}).lookup(name); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestFromFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("example", flag.ContinueOnError)
+	all := fs.Bool("a", false, "")
+	verbose := fs.Bool("verbose", false, "")
+	number := fs.Int("n", 0, "")
+	name := fs.String("name", "", "")
+
+	_, err := Parse(FromFlagSet(fs), []string{"-av", "arg1", "-n42", "--name", "foo", "--", "-v"})
+	if err == nil {
+		t.Fatalf("expected error for unknown -v")
+	}
+
+	args, err := Parse(FromFlagSet(fs), []string{"-an42", "arg1", "--verbose", "--name=foo", "--", "-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !*all || !*verbose || *number != 42 || *name != "foo" {
+		t.Errorf("unexpected values: %v %v %v %v", *all, *verbose, *number, *name)
+	}
+	CompareSlice(t, "Args", args, []string{"arg1", "-a"})
+	CompareSlice(t, "fs.Args", fs.Args(), []string{"arg1", "-a"})
+	if !fs.Parsed() {
+		t.Errorf("fs.Parsed: expected true")
+	}
+
+	_, err = Parse(FromFlagSet(fs), []string{"-n", "NaN"})
+	if !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+
+	_, err = Parse(FromFlagSet(fs), []string{"--help"})
+	if !errors.Is(err, ErrHelp) {
+		t.Errorf("expected ErrHelp, got %#v", err)
+	}
+
+	_, err = Parse(FromFlagSet(fs), []string{"--n=1"})
+	if !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+}
+
+func TestSpecFlagSet(t *testing.T) {
+	spec := newTestSpec()
+	fs := spec.FlagSet()
+	if spec.FlagSet() != fs {
+		t.Errorf("FlagSet returns different *flag.FlagSet")
+	}
+	level := fs.Int("level", 0, "")
+
+	_, args, err := spec.Parse([]string{"-v", "--level=3", "run", "--level", "4", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "Args", args, []string{"cmd"})
+	if *level != 4 {
+		t.Errorf("level: expected 4, got %v", *level)
+	}
+	if v := fs.Lookup("verbose").Value.String(); v != "true" {
+		t.Errorf("verbose: expected true, got %v", v)
+	}
+	if v := fs.Lookup("color").DefValue; v != "" {
+		t.Errorf("color: expected empty default, got %v", v)
+	}
+
+	if err := fs.Set("file", "a.txt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := spec.Lookup("-f").Value(); v != "a.txt" {
+		t.Errorf("-f: expected a.txt, got %v", v)
+	}
+	if err := fs.Set("color", "sometimes"); err == nil {
+		t.Errorf("expected error for invalid choice")
+	}
+
+	var calls []string
+	spec.Lookup("-v").Func = func(name string, values []string) error {
+		calls = append(calls, name+"="+values[0])
+		return nil
+	}
+	if err := fs.Set("verbose", "false"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := spec.Lookup("-v").Count(); n != 0 {
+		t.Errorf("-v: expected 0, got %v", n)
+	}
+	CompareSlice(t, "calls", calls, []string{"--verbose=false"})
+}
+
+func TestFromFlagSetLongOnly(t *testing.T) {
+	fs := flag.NewFlagSet("example", flag.ContinueOnError)
+	all := fs.Bool("a", false, "")
+	verbose := fs.Bool("verbose", false, "")
+	number := fs.Int("n", 0, "")
+	name := fs.String("name", "", "")
+
+	p := &Parser{LongOnly: true}
+	args, err := p.Parse(FromFlagSet(fs), []string{"--a", "-verbose=true", "-n", "42", "arg1", "-name", "foo", "--", "-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !*all || !*verbose || *number != 42 || *name != "foo" {
+		t.Errorf("unexpected values: %v %v %v %v", *all, *verbose, *number, *name)
+	}
+	CompareSlice(t, "Args", args, []string{"arg1", "-a"})
+
+	for _, arg := range []string{"-an42", "-n42"} {
+		if _, err := p.Parse(FromFlagSet(fs), []string{arg}); Code(err) != CodeUnknownOption {
+			t.Errorf("Parse(%q): expected %s, but got %v", arg, CodeUnknownOption, err)
+		}
+	}
+}
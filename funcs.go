@@ -0,0 +1,68 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+type funcsOptions struct {
+	kinds   map[string]Kind
+	handler func(name, value string, hasValue bool) error
+}
+
+// Funcs returns an Options whose Kind method looks up kinds, returning
+// Unknown for the names it does not contain, and whose Option method calls
+// handler. It suits small tools that parse a few options, e.g.
+//
+//	opts := options.Funcs(map[string]options.Kind{
+//		"-v": options.Boolean,
+//		"-o": options.Required,
+//	}, func(name, value string, hasValue bool) error {
+//		...
+//	})
+//
+// The options must not be TakeTwoArgs, TakeNArgs, Rest or TerminatedBy, since
+// the returned Options does not implement OptionsWithOptionN.
+func Funcs(kinds map[string]Kind, handler func(name, value string, hasValue bool) error) Options {
+	return &funcsOptions{kinds, handler}
+}
+
+func (f *funcsOptions) Kind(name string) Kind {
+	return f.kinds[name]
+}
+
+func (f *funcsOptions) Option(name, value string, hasValue bool) error {
+	return f.handler(name, value, hasValue)
+}
+
+type mapOptions struct {
+	kinds  map[string]Kind
+	values map[string][]string
+}
+
+// ParseToMap is like Parse with an Options whose Kind method looks up kinds,
+// and returns the values of the options by name along with the positional
+// arguments. Each occurrence of an option appends its value, or "" if it has
+// none, so that the length is the number of occurrences; those of an option
+// taking several arguments append all of them. A Counter option has its count
+// as its single value.
+func ParseToMap(kinds map[string]Kind, args []string) (map[string][]string, []string, error) {
+	opts := &mapOptions{kinds, make(map[string][]string)}
+	positional, err := Parse(opts, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	return opts.values, positional, nil
+}
+
+func (m *mapOptions) Kind(name string) Kind {
+	return m.kinds[name]
+}
+
+func (m *mapOptions) Option(name, value string, hasValue bool) error {
+	m.values[name] = append(m.values[name], value)
+	return nil
+}
+
+func (m *mapOptions) OptionN(name string, values []string) error {
+	m.values[name] = append(m.values[name], values...)
+	return nil
+}
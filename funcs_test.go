@@ -0,0 +1,56 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestFuncs(t *testing.T) {
+	var history []OptionCall
+	opts := Funcs(map[string]Kind{
+		"-v":       Boolean,
+		"--output": Required,
+	}, func(name, value string, hasValue bool) error {
+		history = append(history, OptionCall{name, value, hasValue})
+		return nil
+	})
+	args, err := Parse(opts, []string{"-v", "--output=x", "file"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"file"})
+	CompareSlice(t, "options", history, []OptionCall{
+		{Name: "-v"},
+		{Name: "--output", Value: "x", HasValue: true},
+	})
+
+	if _, err := Parse(opts, []string{"-x"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+}
+
+func TestParseToMap(t *testing.T) {
+	values, args, err := ParseToMap(map[string]Kind{
+		"-v":       Counter,
+		"-x":       Boolean,
+		"-o":       Required,
+		"--define": TakeTwoArgs,
+	}, []string{"-vvx", "-ofoo", "a", "--define", "k", "v", "-o", "bar", "--", "-x"})
+	if err != nil {
+		t.Fatalf("ParseToMap(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"a", "-x"})
+	CompareSlice(t, "-v", values["-v"], []string{"2"})
+	CompareSlice(t, "-x", values["-x"], []string{""})
+	CompareSlice(t, "-o", values["-o"], []string{"foo", "bar"})
+	CompareSlice(t, "--define", values["--define"], []string{"k", "v"})
+	if len(values) != 4 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if _, _, err := ParseToMap(nil, []string{"-x"}); Code(err) != CodeUnknownOption {
+		t.Errorf("ParseToMap(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type normalizer struct {
+	opts  Options
+	out   []string
+	names []int
+	rest  []string
+}
+
+// Kind reports Counter and Toggle options as Boolean, so that their occurrences are
+// kept in place.
+func (n *normalizer) Kind(name string) Kind {
+	kind := n.opts.Kind(name)
+	if base := kind.Base(); base == Counter || base == Toggle {
+		return Boolean
+	}
+	return kind
+}
+
+func (n *normalizer) Option(name, value string, hasValue bool) error {
+	n.names = append(n.names, len(n.out))
+	switch n.opts.Kind(name).Base() {
+	case Boolean:
+		n.out = append(n.out, name)
+	case Unknown:
+		// The name of a Prefix option includes the rest of the argument.
+		if hasValue {
+			n.out = append(n.out, name, value)
+		} else {
+			n.out = append(n.out, name)
+		}
+	default:
+		n.out = append(n.out, name, value)
+	}
+	return nil
+}
+
+func (n *normalizer) OptionN(name string, values []string) error {
+	if n.opts.Kind(name).Base() == Rest {
+		n.rest = append([]string{name}, values...)
+		return nil
+	}
+	n.names = append(n.names, len(n.out))
+	n.out = append(n.out, name)
+	n.out = append(n.out, values...)
+	return nil
+}
+
+// Normalize parses args like Parse, but instead of invoking the handlers of
+// opts (only Kind is called), it returns the canonicalized argument list in
+// the manner of getopt(1): the options first, each followed by its values
+// as separate elements, then "--", then the positional arguments.
+//
+// Bundled short options are split, and --name=value is split into --name
+// and value. As with getopt(1), an Optional option is always followed by
+// its value, which is empty if not given.
+//
+// A Rest option comes last with its values, in place of "--" and the
+// positional arguments, so it is an error if positional arguments precede it.
+func Normalize(opts Options, args []string) ([]string, error) {
+	n := &normalizer{opts: opts}
+	positional, err := n.parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if n.rest != nil {
+		return append(n.out, n.rest...), nil
+	}
+	n.out = append(n.out, "--")
+	return append(n.out, positional...), nil
+}
+
+func (n *normalizer) parse(args []string) ([]string, error) {
+	positional, err := Parse(n, args)
+	if err != nil {
+		return nil, err
+	}
+	if n.rest != nil && len(positional) > 0 {
+		return nil, fmt.Errorf("options: cannot normalize positional arguments preceding %s", n.rest[0])
+	}
+	return positional, nil
+}
+
+// Getopt is like Normalize, but returns the result as a string for eval in
+// POSIX shells, like getopt(1). Values and positional arguments are quoted,
+// and so are the option names that need it.
+//
+//	eval set -- "$(mytool-getopt "$@")"
+func Getopt(opts Options, args []string) (string, error) {
+	n := &normalizer{opts: opts}
+	positional, err := n.parse(args)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	// Everything but the option names is quoted, whatever the Kind. The
+	// names are quoted only if necessary, since those of Prefix options
+	// contain user input.
+	names := n.names
+	for i, arg := range n.out {
+		if len(names) > 0 && names[0] == i {
+			names = names[1:]
+			sb.WriteString(" " + shellName(arg))
+		} else {
+			sb.WriteString(" " + ShellQuote(arg))
+		}
+	}
+	if n.rest != nil {
+		sb.WriteString(" " + shellName(n.rest[0]))
+		for _, arg := range n.rest[1:] {
+			sb.WriteString(" " + ShellQuote(arg))
+		}
+		return sb.String(), nil
+	}
+	sb.WriteString(" --")
+	for _, arg := range positional {
+		sb.WriteString(" " + ShellQuote(arg))
+	}
+	return sb.String(), nil
+}
+
+// ShellQuote returns s quoted with single quotes for POSIX shells.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellName returns the option name s as is if it is safe for POSIX shells,
+// and quoted otherwise.
+func shellName(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_=+,.:/-") == "" {
+		return s
+	}
+	return ShellQuote(s)
+}
+
+// ExportShell writes the values recorded in the options of s as shell
+// assignments for eval in POSIX shells, one per line and sorted by variable
+// name. vars maps variable names to option names. A Boolean option is
+// exported as the number of times it was specified; other options as their
+// last value or Default.
+//
+//	eval "$(mytool-parse "$@")"
+func (s *Spec) ExportShell(w io.Writer, vars map[string]string) error {
+	names := make([]string, 0, len(vars))
+	for v := range vars {
+		names = append(names, v)
+	}
+	slices.Sort(names)
+	var sb strings.Builder
+	for _, v := range names {
+		if !isShellName(v) {
+			return fmt.Errorf("options: invalid shell variable name %q", v)
+		}
+		o := s.Lookup(vars[v])
+		if o == nil {
+			return fmt.Errorf("options: unknown option %q", vars[v])
+		}
+		var value string
+		if o.flag() {
+			value = strconv.Itoa(o.count)
+		} else {
+			value, _ = o.Value()
+		}
+		sb.WriteString(v + "=" + ShellQuote(value) + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func isShellName(s string) bool {
+	for i, c := range []byte(s) {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && (i == 0 || !('0' <= c && c <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
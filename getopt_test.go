@@ -0,0 +1,112 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	opts := &TestOptions{}
+	args := []string{"arg1", "-abrval1", "--optional", "--required=it's", "--", "-c"}
+	normalized, err := Normalize(opts, args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "Normalize", normalized, []string{
+		"-a", "-b", "-r", "val1", "--optional", "", "--required", "it's", "--", "arg1", "-c",
+	})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, nil)
+
+	s, err := Getopt(&TestOptions{}, []string{"arg1", "-abrval1", "--optional", "--required=it's", "--", "-c", "-s", "k", "v"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := ` -a -b -r 'val1' --optional '' --required 'it'\''s' -- 'arg1' '-c' '-s' 'k' 'v'`
+	if s != expected {
+		t.Errorf("Getopt: expected %q, got %q", expected, s)
+	}
+
+	s, err = Getopt(&TestOptions{}, []string{"-sk", "v", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := ` -s 'k' 'v' -- 'x'`; s != expected {
+		t.Errorf("Getopt: expected %q, got %q", expected, s)
+	}
+
+	normalized, err = Normalize(&restOptions{}, []string{"-ae", "cmd", "--", "-b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "Normalize", normalized, []string{"-a", "-e", "cmd", "--", "-b"})
+	s, err = Getopt(&restOptions{}, []string{"--exec", "it's"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := ` --exec 'it'\''s'`; s != expected {
+		t.Errorf("Getopt: expected %q, got %q", expected, s)
+	}
+	if _, err := Normalize(&restOptions{}, []string{"x", "-e", "cmd"}); err == nil {
+		t.Errorf("expected error for positional arguments preceding a Rest option")
+	}
+
+	s, err = Getopt(&propertyOptions{}, []string{"-D", "x=$(touch /tmp/pwned)", "--define=y", "-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := ` -D 'x=$(touch /tmp/pwned)' --define 'y' -a --`; s != expected {
+		t.Errorf("Getopt: expected %q, got %q", expected, s)
+	}
+
+	s, err = Getopt(&prefixOptions{}, []string{"-W$(touch /tmp/pwned);x", "-Wall", "file"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := ` '-W$(touch /tmp/pwned);x' -Wall -- 'file'`; s != expected {
+		t.Errorf("Getopt: expected %q, got %q", expected, s)
+	}
+
+	if _, err := Normalize(&TestOptions{}, []string{"-x"}); !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+	if _, err := Getopt(&TestOptions{}, []string{"--help"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExportShell(t *testing.T) {
+	spec := newTestSpec()
+	spec.Lookup("--color").Default = "auto"
+	cmd, _, err := spec.Parse([]string{"-vv", "--file=it's.txt", "run", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sb strings.Builder
+	err = cmd.ExportShell(&sb, map[string]string{
+		"VERBOSE": "-v",
+		"FILE":    "--file",
+		"COLOR":   "--color",
+		"DRY_RUN": "--dry-run",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "COLOR='auto'\nDRY_RUN='0'\nFILE='it'\\''s.txt'\nVERBOSE='2'\n"
+	if sb.String() != expected {
+		t.Errorf("expected %q, got %q", expected, sb.String())
+	}
+
+	for _, vars := range []map[string]string{
+		{"1X": "-v"},
+		{"X-Y": "-v"},
+		{"X": "--bogus"},
+	} {
+		if err := cmd.ExportShell(&sb, vars); err == nil {
+			t.Errorf("%v: expected error", vars)
+		}
+	}
+}
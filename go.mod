@@ -1,3 +1,3 @@
 module github.com/cions/go-options
 
-go 1.22
+go 1.23
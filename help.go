@@ -0,0 +1,205 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// HelpStyle selects the layout of the help message written by WriteHelp.
+type HelpStyle int
+
+const (
+	// StandardHelp is the default layout.
+	StandardHelp HelpStyle = iota
+
+	// Help2ManHelp is the GNU layout consumed by help2man: the usage uses
+	// "[OPTION]...", option descriptions start at a fixed column (or on the
+	// next line if the option is too long), and BugReport is written as the
+	// "Report bugs to" line.
+	Help2ManHelp
+)
+
+const helpColumn = 30
+
+// WriteHelp writes the help message of the command to w.
+func (s *Spec) WriteHelp(w io.Writer, style HelpStyle) error {
+	s.init()
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("Usage: " + s.usage(style) + "\n")
+	if s.Summary != "" {
+		bw.WriteString(s.Summary + "\n")
+	}
+
+	var rows [][2]string
+	for _, o := range s.Options {
+		if !o.Hidden {
+			rows = append(rows, [2]string{optionLabel(o), o.Help})
+		}
+	}
+	writeHelpSection(bw, "Options:", rows, style)
+
+	var inherited [][2]string
+	for c := s.parent; c != nil; c = c.parent {
+		for _, o := range c.Options {
+			if !o.Hidden {
+				inherited = append(inherited, [2]string{optionLabel(o), o.Help})
+			}
+		}
+	}
+	writeHelpSection(bw, "Global options:", inherited, style)
+
+	rows = nil
+	for _, a := range s.Positional {
+		if a.Help != "" {
+			rows = append(rows, [2]string{a.Name, a.Help})
+		}
+	}
+	writeHelpSection(bw, "Arguments:", rows, style)
+
+	rows = nil
+	for _, cmd := range s.Commands {
+		if !cmd.Hidden {
+			rows = append(rows, [2]string{cmd.Name, cmd.Summary})
+		}
+	}
+	writeHelpSection(bw, "Commands:", rows, style)
+
+	if len(s.Examples) > 0 {
+		bw.WriteString("\nExamples:\n")
+		for _, example := range s.Examples {
+			bw.WriteString("  " + example + "\n")
+		}
+	}
+
+	if style == Help2ManHelp && s.root().BugReport != "" {
+		bw.WriteString("\nReport bugs to: " + s.root().BugReport + "\n")
+	}
+	return bw.Flush()
+}
+
+// WriteVersion writes the GNU-style version line ("NAME VERSION"), which
+// help2man uses for the NAME section, to w.
+func (s *Spec) WriteVersion(w io.Writer) error {
+	_, err := io.WriteString(w, s.root().Name+" "+s.root().Version+"\n")
+	return err
+}
+
+func (s *Spec) root() *Spec {
+	s.init()
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+func (s *Spec) path() string {
+	if s.parent == nil {
+		return s.Name
+	}
+	return s.parent.path() + " " + s.Name
+}
+
+func (s *Spec) usage(style HelpStyle) string {
+	var sb strings.Builder
+	sb.WriteString(s.path())
+	if style == Help2ManHelp {
+		sb.WriteString(" [OPTION]...")
+	} else {
+		sb.WriteString(" [OPTIONS]")
+	}
+	if len(s.Commands) > 0 {
+		sb.WriteString(" COMMAND [ARGS...]")
+	}
+	for _, a := range s.Positional {
+		if a.Variadic {
+			sb.WriteString(" [" + a.Name + "...]")
+		} else {
+			sb.WriteString(" " + a.Name)
+		}
+	}
+	return sb.String()
+}
+
+func optionLabel(o *OptionSpec) string {
+	var sb strings.Builder
+	short, long := o.Short(), o.Long()
+	if short != "" {
+		sb.WriteString(short)
+		if long != "" {
+			sb.WriteString(", ")
+		}
+	} else {
+		sb.WriteString("    ")
+	}
+	sb.WriteString(long)
+	metavar := o.Metavar
+	if metavar == "" {
+		metavar = "VALUE"
+		if o.kind().Base() == Property {
+			metavar = "KEY=VALUE"
+		}
+	}
+	switch kind := o.kind(); kind.Base() {
+	case Property:
+		if long != "" {
+			sb.WriteString(" " + metavar)
+		} else {
+			sb.WriteString(metavar)
+		}
+	case Required:
+		if long != "" {
+			sb.WriteString("=" + metavar)
+		} else {
+			sb.WriteString(" " + metavar)
+		}
+	case Optional:
+		if long != "" {
+			sb.WriteString("[=" + metavar + "]")
+		} else {
+			sb.WriteString("[" + metavar + "]")
+		}
+	case TakeTwoArgs:
+		for range kind.NArgs() {
+			sb.WriteString(" " + metavar)
+		}
+	case Rest:
+		sb.WriteString(" " + metavar + "...")
+	case Terminated:
+		if terms := kind.terminators(); terms != nil {
+			sb.WriteString(" " + metavar + "... " + terms[0])
+		}
+	}
+	return sb.String()
+}
+
+func writeHelpSection(w *bufio.Writer, title string, rows [][2]string, style HelpStyle) {
+	if len(rows) == 0 {
+		return
+	}
+	w.WriteString("\n" + title + "\n")
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+	column := width + 4
+	if style == Help2ManHelp {
+		column = helpColumn
+	}
+	for _, row := range rows {
+		label, help := "  "+row[0], row[1]
+		switch {
+		case help == "":
+			w.WriteString(label + "\n")
+		case len(label)+2 > column:
+			w.WriteString(label + "\n" + strings.Repeat(" ", column) + help + "\n")
+		default:
+			w.WriteString(label + strings.Repeat(" ", column-len(label)) + help + "\n")
+		}
+	}
+}
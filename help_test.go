@@ -0,0 +1,86 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteHelp(t *testing.T) {
+	spec := newTestSpec()
+	spec.Version = "1.0.0"
+	spec.BugReport = "<bugs@example.com>"
+	spec.Options = append(spec.Options,
+		&OptionSpec{Names: []string{"-s", "--set"}, Kind: TakeTwoArgs, Metavar: "KEY", Help: "set a variable to a very long value"},
+		&OptionSpec{Names: []string{"--debug"}, Hidden: true},
+	)
+
+	tests := []struct {
+		name     string
+		spec     *Spec
+		style    HelpStyle
+		expected string
+	}{
+		{"standard", spec, StandardHelp, `Usage: example [OPTIONS] COMMAND [ARGS...]
+An example command
+
+Options:
+  -v, --verbose       verbose output
+  -f, --file=FILE     input file
+      --color[=WHEN]  colorize output
+  -s, --set KEY KEY   set a variable to a very long value
+
+Commands:
+  run  Run a command
+`},
+		{"help2man", spec, Help2ManHelp, `Usage: example [OPTION]... COMMAND [ARGS...]
+An example command
+
+Options:
+  -v, --verbose               verbose output
+  -f, --file=FILE             input file
+      --color[=WHEN]          colorize output
+  -s, --set KEY KEY           set a variable to a very long value
+
+Commands:
+  run                         Run a command
+
+Report bugs to: <bugs@example.com>
+`},
+		{"subcommand", spec.Commands[0], StandardHelp, `Usage: example run [OPTIONS] COMMAND [ARGS...]
+Run a command
+
+Options:
+  -n, --dry-run  do not run
+
+Global options:
+  -v, --verbose       verbose output
+  -f, --file=FILE     input file
+      --color[=WHEN]  colorize output
+  -s, --set KEY KEY   set a variable to a very long value
+
+Arguments:
+  COMMAND  command to run
+  ARGS     arguments
+`},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := tt.spec.WriteHelp(&sb, tt.style); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if actual := sb.String(); actual != tt.expected {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", tt.name, tt.expected, actual)
+		}
+	}
+
+	var sb strings.Builder
+	if err := spec.Commands[0].WriteVersion(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := sb.String(); actual != "example 1.0.0\n" {
+		t.Errorf("WriteVersion: expected %q, got %q", "example 1.0.0\n", actual)
+	}
+}
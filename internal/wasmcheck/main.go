@@ -0,0 +1,42 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Command wasmcheck is a minimal program using the core parser. CI builds it
+// with TinyGo and for wasm targets to ensure that the core package keeps
+// compiling there.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cions/go-options"
+)
+
+type opts struct {
+	verbose bool
+}
+
+func (o *opts) Kind(name string) options.Kind {
+	switch name {
+	case "-v", "--verbose":
+		return options.Boolean
+	default:
+		return options.Unknown
+	}
+}
+
+func (o *opts) Option(name, value string, hasValue bool) error {
+	o.verbose = true
+	return nil
+}
+
+func main() {
+	o := &opts{}
+	args, err := options.Parse(o, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "wasmcheck: error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(o.verbose, args)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ParseJSON is like Parse, but takes the argument list as a JSON array of
+// strings, as produced by editors, task runners and RPC layers, avoiding
+// lossy re-quoting through a shell. It fails if data is not an array or an
+// element is not a string.
+func ParseJSON(opts Options, data []byte) ([]string, error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return nil, fmt.Errorf("options: invalid JSON argument list: %w", err)
+	}
+	if elems == nil {
+		return nil, fmt.Errorf("options: invalid JSON argument list: expected an array, got null")
+	}
+	args := make([]string, len(elems))
+	for i, elem := range elems {
+		if len(elem) == 0 || elem[0] != '"' {
+			return nil, fmt.Errorf("options: invalid JSON argument list: element %d is not a string: %s", i, bytes.TrimSpace(elem))
+		}
+		if err := json.Unmarshal(elem, &args[i]); err != nil {
+			return nil, fmt.Errorf("options: invalid JSON argument list: element %d: %w", i, err)
+		}
+	}
+	return Parse(opts, args)
+}
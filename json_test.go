@@ -0,0 +1,36 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	opts := &TestOptions{}
+	args, err := ParseJSON(opts, []byte(`["-a", "x y", "--required", "it's \"quoted\"", "--", "é"]`))
+	if err != nil {
+		t.Fatalf("ParseJSON(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x y", "é"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-a", "", false},
+		{"--required", `it's "quoted"`, true},
+	})
+
+	if args, err := ParseJSON(&TestOptions{}, []byte(`[]`)); err != nil || len(args) != 0 {
+		t.Errorf("ParseJSON([]): got %v, %v", args, err)
+	}
+
+	for _, input := range []string{`null`, `"-a"`, `["-a", 1]`, `["-a", null]`, `[`, `{"a": "b"}`} {
+		if _, err := ParseJSON(&TestOptions{}, []byte(input)); err == nil || errors.Is(err, ErrCmdline) {
+			t.Errorf("%s: expected a non-command-line error, got %v", input, err)
+		}
+	}
+
+	if _, err := ParseJSON(&TestOptions{}, []byte(`["--bogus"]`)); !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %v", err)
+	}
+}
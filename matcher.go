@@ -0,0 +1,102 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// matcher is an index of option names built once per spec. Exact lookups
+// are O(1), and prefix queries are O(log n) binary searches over the sorted
+// names, returning subslices without allocation.
+type matcher struct {
+	names    []string
+	options  map[string]*OptionSpec
+	prefixes []string
+}
+
+func newMatcher(opts []*OptionSpec) *matcher {
+	m := &matcher{options: make(map[string]*OptionSpec)}
+	for _, o := range opts {
+		for _, name := range o.Names {
+			if _, ok := m.options[name]; !ok {
+				m.names = append(m.names, name)
+			}
+			m.options[name] = o
+			if o.Kind&Prefix != 0 {
+				m.prefixes = append(m.prefixes, name)
+			}
+		}
+	}
+	slices.Sort(m.names)
+	// The longest prefix matches first.
+	slices.SortStableFunc(m.prefixes, func(a, b string) int { return len(b) - len(a) })
+	return m
+}
+
+// lookup returns the option named name, or else the Prefix option whose name
+// is a prefix of name.
+func (m *matcher) lookup(name string) *OptionSpec {
+	if o := m.options[name]; o != nil {
+		return o
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return m.options[prefix]
+		}
+	}
+	return nil
+}
+
+func (m *matcher) withPrefix(prefix string) []string {
+	lo := sort.SearchStrings(m.names, prefix)
+	hi := lo + sort.Search(len(m.names)-lo, func(i int) bool {
+		return !strings.HasPrefix(m.names[lo+i], prefix)
+	})
+	return m.names[lo:hi:hi]
+}
+
+// Candidates returns the names of the options, including those of the parent
+// commands, that start with prefix, in sorted order. Hidden options are
+// included.
+func (s *Spec) Candidates(prefix string) []string {
+	s.init()
+	if s.parent == nil {
+		return s.match.withPrefix(prefix)
+	}
+	var names []string
+	for c := s; c != nil; c = c.parent {
+		names = append(names, c.match.withPrefix(prefix)...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// OptionNames implements OptionsWithNames. It returns the long and short
+// names of the options, including those of the parent commands, in sorted
+// order. The returned slice may be modified by the caller.
+func (s *Spec) OptionNames() []string {
+	return slices.Clone(s.Candidates("-"))
+}
+
+// Suggest returns the names of the options sharing the longest common prefix
+// with name, for "did you mean" messages. At least one character after the
+// dashes must be shared. Hidden options are not suggested.
+func (s *Spec) Suggest(name string) []string {
+	dashes := len(name) - len(strings.TrimLeft(name, "-"))
+	for n := len(name); n > dashes; n-- {
+		var names []string
+		for _, candidate := range s.Candidates(name[:n]) {
+			if !s.Lookup(candidate).Hidden && candidate != name {
+				names = append(names, candidate)
+			}
+		}
+		if len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
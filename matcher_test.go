@@ -0,0 +1,73 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	spec := newTestSpec()
+	CompareSlice(t, "--", spec.Candidates("--"), []string{"--color", "--file", "--verbose"})
+	CompareSlice(t, "--c", spec.Candidates("--c"), []string{"--color"})
+	CompareSlice(t, "--x", spec.Candidates("--x"), []string{})
+	CompareSlice(t, "run --", spec.Commands[0].Candidates("--"), []string{"--color", "--dry-run", "--file", "--verbose"})
+	CompareSlice(t, "run -", spec.Commands[0].Candidates("-"), []string{
+		"--color", "--dry-run", "--file", "--verbose", "-f", "-n", "-v",
+	})
+}
+
+func TestOptionNames(t *testing.T) {
+	spec := newTestSpec()
+	names := spec.OptionNames()
+	CompareSlice(t, "OptionNames", names, []string{"--color", "--file", "--verbose", "-f", "-v"})
+	names[0] = "--modified"
+	CompareSlice(t, "--", spec.Candidates("--"), []string{"--color", "--file", "--verbose"})
+}
+
+func TestSuggest(t *testing.T) {
+	spec := newTestSpec()
+	spec.Options = append(spec.Options, &OptionSpec{Names: []string{"--colour"}, Hidden: true})
+	CompareSlice(t, "--colr", spec.Suggest("--colr"), []string{"--color"})
+	CompareSlice(t, "--verbsoe", spec.Suggest("--verbsoe"), []string{"--verbose"})
+	CompareSlice(t, "--fil", spec.Suggest("--fil"), []string{"--file"})
+	CompareSlice(t, "--xyz", spec.Suggest("--xyz"), []string{})
+	CompareSlice(t, "run --dry", spec.Commands[0].Suggest("--dry"), []string{"--dry-run"})
+}
+
+func newLargeSpec(n int) *Spec {
+	spec := &Spec{}
+	for i := range n {
+		spec.Options = append(spec.Options, &OptionSpec{
+			Names: []string{fmt.Sprintf("--option-%05d", i)},
+			Kind:  Required,
+		})
+	}
+	return spec
+}
+
+func BenchmarkSpecKind(b *testing.B) {
+	spec := newLargeSpec(1000)
+	spec.init()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if spec.Kind("--option-00500") != Required {
+			b.Fatal("unexpected kind")
+		}
+	}
+}
+
+func BenchmarkSpecCandidates(b *testing.B) {
+	spec := newLargeSpec(1000)
+	spec.init()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if len(spec.Candidates("--option-005")) != 100 {
+			b.Fatal("unexpected candidates")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"time"
+)
+
+// Metrics receives measurements of parses, e.g. to export them as telemetry
+// on command usage. See Parser.Metrics.
+type Metrics interface {
+	// ObserveHandler is called after each token is handled by the Option,
+	// OptionN or Arg method, with the time taken and the error returned.
+	ObserveHandler(tok Token, d time.Duration, err error)
+
+	// ObserveParse is called at the end of each parse with its statistics
+	// and the error returned by the parse function.
+	ObserveParse(stats Stats, err error)
+}
+
+// Stats summarizes a parse.
+type Stats struct {
+	// Tokens is the number of tokens scanned, including the --.
+	Tokens int
+
+	// Options is the number of options handled.
+	Options int
+
+	// Positional is the number of positional arguments.
+	Positional int
+
+	// HandlerTime is the total time taken by the Option, OptionN and Arg
+	// methods.
+	HandlerTime time.Duration
+
+	// Duration is the total time taken by the parse.
+	Duration time.Duration
+}
+
+func (p *Parser) observe(tok *Token, start time.Time, err error) {
+	d := time.Since(start)
+	p.stats.Tokens++
+	if tok.Kind == OptionToken {
+		p.stats.Options++
+	}
+	p.stats.HandlerTime += d
+	p.Metrics.ObserveHandler(*tok, d, err)
+}
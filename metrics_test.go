@@ -0,0 +1,47 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	handled []string
+	stats   []Stats
+	errs    []error
+}
+
+func (m *testMetrics) ObserveHandler(tok Token, d time.Duration, err error) {
+	m.handled = append(m.handled, tok.Kind.String()+" "+tok.Name+tok.Value)
+}
+
+func (m *testMetrics) ObserveParse(stats Stats, err error) {
+	m.stats = append(m.stats, stats)
+	m.errs = append(m.errs, err)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{}
+	p := &Parser{Metrics: m}
+	if _, err := p.Parse(&TestOptions{}, []string{"-ab", "x", "--", "y"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "handled", m.handled, []string{"option -a", "option -b", "positional x", "ddash ", "positional y"})
+	s := m.stats[0]
+	if s.Tokens != 5 || s.Options != 2 || s.Positional != 2 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.Duration < s.HandlerTime {
+		t.Errorf("Duration %v is less than HandlerTime %v", s.Duration, s.HandlerTime)
+	}
+
+	if _, err := p.Parse(&TestOptions{}, []string{"-a", "--bogus"}); err == nil {
+		t.Fatalf("Parse(): expected error")
+	}
+	if len(m.stats) != 2 || m.stats[1].Options != 1 || m.errs[1] == nil {
+		t.Errorf("unexpected stats of the failed parse: %+v, %v", m.stats, m.errs)
+	}
+}
@@ -5,9 +5,14 @@
 package options
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
@@ -15,28 +20,18 @@ var (
 	ErrCmdline = errors.New("invalid command line")
 
 	// ErrHelp is the error returned if the user requested to show help message.
-	ErrHelp = Errorf("help requested")
+	ErrHelp error = &Error{Code: CodeHelp, format: "help requested"}
 
 	// ErrVersion is the error returned if the user requested to show version information.
-	ErrVersion = Errorf("version requested")
+	ErrVersion error = &Error{Code: CodeVersion, format: "version requested"}
 
 	// ErrUnknown is the error returned if an unknown option is provided.
-	ErrUnknown = Errorf("unknown option")
+	ErrUnknown error = &Error{Code: CodeUnknownOption, format: "unknown option"}
 
 	// ErrNoSubcommand is the error returned if no subcommand is provided.
-	ErrNoSubcommand = Errorf("no subcommand was provided")
+	ErrNoSubcommand error = &Error{Code: CodeNoSubcommand, format: "no subcommand was provided"}
 )
 
-type cmdlineError struct{ error }
-
-func (e cmdlineError) Error() string        { return e.error.Error() }
-func (e cmdlineError) Unwrap() error        { return errors.Unwrap(e.error) }
-func (e cmdlineError) Is(target error) bool { return target == ErrCmdline }
-
-func Errorf(format string, a ...any) error {
-	return cmdlineError{fmt.Errorf(format, a...)}
-}
-
 // Kind defines how the option takes arguments.
 type Kind int
 
@@ -46,8 +41,200 @@ const (
 	Required
 	Optional
 	TakeTwoArgs
+
+	// Counter is a Boolean option whose occurrences are counted, e.g. -vvv.
+	// The count is passed once per name at the end of the options to the
+	// OptionCount method, or else to Option as a decimal value.
+	Counter
+
+	// Rest is the Kind of options that take all the remaining arguments,
+	// including any --, which are passed to the OptionN method. A value
+	// attached to a short option is the first of them.
+	Rest
+
+	// Terminated is the base Kind of TerminatedBy.
+	Terminated
+
+	// Property is the Kind of option families such as -Dkey=value, which
+	// take an argument like Required options. The argument is split at the
+	// first = and passed to the OptionProperty method, or else to Option
+	// unchanged. The rest of a short option is always its argument, so
+	// -Dfoo=bar is not a group of short options.
+	Property
+
+	// Toggle is a Boolean option whose occurrences alternately turn it on and
+	// off. Each occurrence is passed to the OptionToggle method with its
+	// index among the occurrences of the name, or else to Option with the
+	// value "true" or "false".
+	Toggle
+)
+
+// Once is a modifier of Kind for options that may be specified at most once,
+// e.g. Required | Once. The parser reports a repeated name as an error with
+// CodeRepeatedOption. Aliases are distinct names, so -o and --output may
+// each be given once.
+const Once Kind = 1 << 8
+
+// Prefix is a modifier of Kind for option families such as -W and -f of
+// compilers. A short option whose Kind has Prefix takes the rest of its
+// argument as part of its name, e.g. -Wno-unused, and is then processed
+// like a long option with that name, whose value follows =. Long names are
+// passed to Kind as a whole, so Kind can match their prefixes itself. Spec
+// matches the names of its Prefix options as prefixes.
+const Prefix Kind = 1 << 9
+
+// DashValue and NoDashValue are modifiers of Kind for Required and Property
+// options. DashValue states that the value given as the next argument may
+// start with a dash, as in --offset -5, which is the default in every mode
+// of the parser. NoDashValue rejects such a value as a likely mistake, as in
+// --output --verbose; a value attached with = or to a short option is
+// still accepted.
+const (
+	DashValue   Kind = 1 << 10
+	NoDashValue Kind = 1 << 11
+)
+
+// A Kind is made of a base Kind in the lowest byte, modifiers such as Once in
+// the second byte, and a parameter, such as the number of arguments of
+// TakeNArgs, from kindParamShift.
+const (
+	kindBaseMask   = 0xff
+	kindParamShift = 16
 )
 
+var kindModifiers = []struct {
+	mod  Kind
+	name string
+}{
+	{Once, "Once"},
+	{Prefix, "Prefix"},
+	{DashValue, "DashValue"},
+	{NoDashValue, "NoDashValue"},
+	{Deprecated, "Deprecated"},
+	{File, "File"},
+	{NewFile, "NewFile"},
+}
+
+// TakeNArgs returns the Kind of options taking n arguments, which are passed
+// to the OptionN method. Its base Kind is TakeTwoArgs, and TakeNArgs(2) is
+// TakeTwoArgs. It panics if n is not positive.
+func TakeNArgs(n int) Kind {
+	if n < 1 {
+		panic("options: TakeNArgs requires a positive number of arguments")
+	}
+	if n == 2 {
+		return TakeTwoArgs
+	}
+	return TakeTwoArgs | Kind(n)<<kindParamShift
+}
+
+// terminatorSets interns the terminators of TerminatedBy, whose index plus
+// one is the parameter of the Kind.
+var terminatorSets struct {
+	sync.RWMutex
+	sets [][]string
+}
+
+// TerminatedBy returns the Kind of options taking the arguments up to one of
+// terminators, like -exec of find(1) does up to ";" or "+". The arguments
+// and the terminator that ended them, as the last element, are passed to the
+// OptionN method. A value attached to a short option is the first argument.
+// It panics if no terminator is given.
+func TerminatedBy(terminators ...string) Kind {
+	if len(terminators) == 0 {
+		panic("options: TerminatedBy requires a terminator")
+	}
+	terminatorSets.Lock()
+	defer terminatorSets.Unlock()
+	i := slices.IndexFunc(terminatorSets.sets, func(set []string) bool {
+		return slices.Equal(set, terminators)
+	})
+	if i < 0 {
+		i = len(terminatorSets.sets)
+		terminatorSets.sets = append(terminatorSets.sets, slices.Clone(terminators))
+	}
+	return Terminated | Kind(i+1)<<kindParamShift
+}
+
+// Terminators returns the terminators of TerminatedBy, or nil if k is not
+// made by TerminatedBy.
+func (k Kind) Terminators() []string {
+	return slices.Clone(k.terminators())
+}
+
+func (k Kind) terminators() []string {
+	i := int(k>>kindParamShift) - 1
+	if k.Base() != Terminated || i < 0 {
+		return nil
+	}
+	terminatorSets.RLock()
+	defer terminatorSets.RUnlock()
+	if i >= len(terminatorSets.sets) {
+		return nil
+	}
+	return terminatorSets.sets[i]
+}
+
+// Base returns k without its parameter, e.g. TakeTwoArgs for TakeNArgs(3).
+func (k Kind) Base() Kind {
+	return k & kindBaseMask
+}
+
+// NArgs returns the number of arguments an option of Kind k takes: 0 for
+// Boolean, 1 for Required, Optional and Property, and n for TakeNArgs(n). It
+// returns 0 for Rest and TerminatedBy, which take a variable number of
+// arguments.
+func (k Kind) NArgs() int {
+	switch k.Base() {
+	case Required, Optional, Property:
+		return 1
+	case TakeTwoArgs:
+		if n := int(k >> kindParamShift); n != 0 {
+			return n
+		}
+		return 2
+	default:
+		return 0
+	}
+}
+
+var kindStrings = [...]string{
+	Unknown:     "Unknown",
+	Boolean:     "Boolean",
+	Required:    "Required",
+	Optional:    "Optional",
+	TakeTwoArgs: "TakeTwoArgs",
+	Counter:     "Counter",
+	Rest:        "Rest",
+	Terminated:  "Terminated",
+	Property:    "Property",
+	Toggle:      "Toggle",
+}
+
+// String returns the name of the Kind constant, or an expression such as
+// TakeNArgs(3).
+func (k Kind) String() string {
+	for _, m := range kindModifiers {
+		if k&m.mod != 0 {
+			return (k &^ m.mod).String() + "|" + m.name
+		}
+	}
+	if k.Base() == TakeTwoArgs && k != TakeTwoArgs {
+		return "TakeNArgs(" + strconv.Itoa(k.NArgs()) + ")"
+	}
+	if terms := k.terminators(); terms != nil {
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = strconv.Quote(term)
+		}
+		return "TerminatedBy(" + strings.Join(quoted, ", ") + ")"
+	}
+	if k >= 0 && int(k) < len(kindStrings) {
+		return kindStrings[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
 // Options is an interface that defines the set of options and stores the parsed result.
 type Options interface {
 	// Kind is called for each option with name (including dashes) and returns Kind.
@@ -59,7 +246,7 @@ type Options interface {
 
 // OptionsWithOptionN is an interface that adds the OptionN method to Options.
 //
-// OptionN is called for each TakeTwoArgs option instead of Option.
+// OptionN is called for each TakeTwoArgs and TakeNArgs option instead of Option.
 type OptionsWithOptionN interface {
 	Options
 
@@ -78,175 +265,659 @@ type OptionsWithArg interface {
 // OptionsWithArgs is an interface that adds the Args method to Options.
 //
 // Args is called once at the end, with the positional arguments before and after the --.
+// before and after may share storage with the argument list and with the
+// slice returned by the parse function, so their elements must not be
+// modified. Their capacities are clipped to their lengths, so appending to
+// them always allocates and never corrupts the returned slice.
 type OptionsWithArgs interface {
 	Options
 
 	Args(before, after []string) error
 }
 
+// IndexedArg is a positional argument with its index in the argument list.
+type IndexedArg struct {
+	Index int
+	Value string
+}
+
+// OptionsWithArgsIndexed is an interface that adds the ArgsIndexed method to Options.
+//
+// ArgsIndexed is called once at the end, after Args, with the positional
+// arguments before and after the -- and their indices in the argument list,
+// e.g. to re-slice or quote the original command line around them.
+type OptionsWithArgsIndexed interface {
+	Options
+
+	ArgsIndexed(before, after []IndexedArg) error
+}
+
+// OptionsWithPreParse is an interface that adds the PreParse method to Options.
+//
+// PreParse is called with the argument list before any other method, e.g.
+// to set defaults from the environment.
+type OptionsWithPreParse interface {
+	Options
+
+	PreParse(args []string) error
+}
+
+// OptionsWithPostParse is an interface that adds the PostParse method to Options.
+//
+// PostParse is called with the positional arguments after all other methods
+// succeeded, e.g. to apply implied defaults or check combinations of options.
+// positional is nil for ParseStream.
+type OptionsWithPostParse interface {
+	Options
+
+	PostParse(positional []string) error
+}
+
+// OptionsWithRaw is an interface that adds the OptionRaw method to Options.
+//
+// If implemented, OptionRaw is called instead of Option, with raw set to the
+// untouched arguments the option was taken from, e.g. ["--number=NaN"],
+// ["-abn5"] for each of -a, -b and -n, or ["-n", "5"]. raw is nil if the
+// option does not come from an argument list, as with ParseValues.
+type OptionsWithRaw interface {
+	Options
+
+	OptionRaw(name, value string, hasValue bool, raw []string) error
+}
+
+// OptionsWithCount is an interface that adds the OptionCount method to Options.
+//
+// OptionCount is called for each Counter option instead of Option, once per
+// name at the end of the options, with the number of occurrences. Aliases
+// are reported separately, so the counts should be added up.
+type OptionsWithCount interface {
+	Options
+
+	OptionCount(name string, count int) error
+}
+
+// OptionsWithProperty is an interface that adds the OptionProperty method to
+// Options.
+//
+// OptionProperty is called for each Property option instead of Option, with
+// the key and value of its argument. hasValue is false if the argument has
+// no =, as in -Dfoo.
+type OptionsWithProperty interface {
+	Options
+
+	OptionProperty(name, key, value string, hasValue bool) error
+}
+
+// OptionsWithToggle is an interface that adds the OptionToggle method to
+// Options.
+//
+// OptionToggle is called for each occurrence of a Toggle option instead of
+// Option. index counts the previous occurrences of name, and on is true for
+// the even ones. Aliases are counted separately.
+type OptionsWithToggle interface {
+	Options
+
+	OptionToggle(name string, on bool, index int) error
+}
+
+// OptionsWithContext is an interface that adds the OptionContext method to Options.
+//
+// If implemented, OptionContext is called instead of Option and OptionRaw,
+// with the context given to ParseContext, or context.Background, so that
+// handlers doing I/O can honor cancellation and deadlines.
+type OptionsWithContext interface {
+	Options
+
+	OptionContext(ctx context.Context, name, value string, hasValue bool) error
+}
+
 const (
 	earlyExit = 1 << iota
 	noDDash
+	noCollect
+	strictOptional
+	shortEquals
+	boolValues
+	digitOptions
+	plusOptions
+	singleDashLong
+	passthrough
+	stopAtUnknown
+	ignoreCase
+	abbreviations
+	negativeNumbers
+	ddashGroups
+	noBundling
+	knownOnly
+	longOnly
+	strictPOSIX
 )
 
-func parse(opts Options, args []string, flags int) ([]string, error) {
-	var positional []string
-	var exited bool
-
-	for len(args) > 0 {
-		var name, value string
-		var hasValue bool
-		switch {
-		case args[0] == "--" && flags&noDDash == 0:
-			if aopts, ok := opts.(OptionsWithArg); ok {
-				for i, arg := range args[1:] {
-					if err := aopts.Arg(i+len(positional), arg, true); err != nil {
-						return nil, err
+// handlers holds opts and its optional interfaces, which are resolved once
+// per parse instead of on every token.
+type handlers struct {
+	ctx   context.Context
+	opts  Options
+	aopts OptionsWithArg
+	sopts OptionsWithArgs
+	iopts OptionsWithArgsIndexed
+	nopts OptionsWithOptionN
+	ropts OptionsWithRaw
+	copts OptionsWithContext
+	kopts OptionsWithCount
+	popts OptionsWithProperty
+	topts OptionsWithToggle
+	wopts OptionsWithWarning
+	pre   OptionsWithPreParse
+	post  OptionsWithPostParse
+}
+
+func newHandlers(opts Options) handlers {
+	h := handlers{ctx: context.Background(), opts: opts}
+	h.aopts, _ = opts.(OptionsWithArg)
+	h.sopts, _ = opts.(OptionsWithArgs)
+	h.iopts, _ = opts.(OptionsWithArgsIndexed)
+	h.nopts, _ = opts.(OptionsWithOptionN)
+	h.ropts, _ = opts.(OptionsWithRaw)
+	h.copts, _ = opts.(OptionsWithContext)
+	h.kopts, _ = opts.(OptionsWithCount)
+	h.popts, _ = opts.(OptionsWithProperty)
+	h.topts, _ = opts.(OptionsWithToggle)
+	h.wopts, _ = opts.(OptionsWithWarning)
+	h.pre, _ = opts.(OptionsWithPreParse)
+	h.post, _ = opts.(OptionsWithPostParse)
+	return h
+}
+
+func (h *handlers) option(name, value string, hasValue bool, raw []string) error {
+	var err error
+	switch {
+	case h.copts != nil:
+		err = h.copts.OptionContext(h.ctx, name, value, hasValue)
+	case h.ropts != nil:
+		err = h.ropts.OptionRaw(name, value, hasValue, raw)
+	default:
+		err = h.opts.Option(name, value, hasValue)
+	}
+	if err == ErrUnknown {
+		return errorf(CodeUnknownOption, name, "unknown option %q", name)
+	} else if err != nil {
+		if hasValue && isSecret(h.opts, name) {
+			err = redactError(err, value)
+		}
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+func (h *handlers) optionN(name string, values []string) error {
+	if h.nopts == nil {
+		panic("Kind() returns TakeTwoArgs, TakeNArgs, Rest or TerminatedBy but OptionN method is not implemented")
+	}
+	if err := h.nopts.OptionN(name, values); err != nil {
+		if isSecret(h.opts, name) {
+			err = redactError(err, values...)
+		}
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+func (h *handlers) property(name, arg string, raw []string) error {
+	key, value, hasValue := strings.Cut(arg, "=")
+	if key == "" {
+		return errorf(CodeInvalidValue, name, "option %s requires an argument of the form KEY=VALUE", name)
+	}
+	if h.popts == nil {
+		return h.option(name, arg, true, raw)
+	}
+	if err := h.popts.OptionProperty(name, key, value, hasValue); err != nil {
+		if hasValue && isSecret(h.opts, name) {
+			err = redactError(err, value)
+		}
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+func (h *handlers) count(name string, n int) error {
+	if h.kopts == nil {
+		return h.option(name, strconv.Itoa(n), true, nil)
+	}
+	if err := h.kopts.OptionCount(name, n); err != nil {
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+func (h *handlers) toggle(name string, index int, raw []string) error {
+	on := index%2 == 0
+	if h.topts == nil {
+		return h.option(name, strconv.FormatBool(on), true, raw)
+	}
+	if err := h.topts.OptionToggle(name, on, index); err != nil {
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+// optionState is the state of the options accumulated during a parse.
+type optionState struct {
+	once     []string
+	counters []counter
+	toggles  []counter
+}
+
+// checkOnce fails if name is a Once option that has already occurred.
+func (st *optionState) checkOnce(name string, kind Kind) error {
+	if kind&Once != 0 {
+		if slices.Contains(st.once, name) {
+			return errorf(CodeRepeatedOption, name, "option %s specified multiple times", name)
+		}
+		st.once = append(st.once, name)
+	}
+	return nil
+}
+
+// checkValue performs the checks of Parse on a value of the option name of
+// Kind kind before it is passed to the handlers: it is resolved with
+// p.Resolvers, and must name a file if name is a File or NewFile option. It
+// returns the resolved value.
+func (p *Parser) checkValue(name string, kind Kind, value string) (string, error) {
+	var err error
+	if p.Resolvers != nil {
+		if value, err = p.resolve(name, value); err != nil {
+			return "", err
+		}
+	}
+	if kind&(File|NewFile) != 0 {
+		if err := checkFile(name, value, kind); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// checkValues is like checkValue for the values of a TakeTwoArgs, Rest or
+// Terminated option. It copies values only if a value is resolved.
+func (p *Parser) checkValues(name string, kind Kind, values []string) ([]string, error) {
+	if p.Resolvers == nil && kind&(File|NewFile) == 0 {
+		return values, nil
+	}
+	var resolved []string
+	for i, ref := range values {
+		value, err := p.checkValue(name, kind, ref)
+		if err != nil {
+			return nil, err
+		}
+		if value != ref && resolved == nil {
+			resolved = slices.Clone(values)
+		}
+		if resolved != nil {
+			resolved[i] = value
+		}
+	}
+	if resolved == nil {
+		return values, nil
+	}
+	return resolved, nil
+}
+
+// optionToken processes the current OptionToken of t, whose arguments are in
+// args.
+func (p *Parser) optionToken(h *handlers, t *Tokenizer, st *optionState, args []string) error {
+	tok := &t.tok
+	if err := st.checkOnce(tok.Name, t.kind); err != nil {
+		return err
+	}
+	if t.kind.Base() == Counter {
+		st.counters, _ = addCount(st.counters, tok.Name)
+		return nil
+	}
+	if tok.Values != nil {
+		values, err := p.checkValues(tok.Name, t.kind, tok.Values)
+		if err != nil {
+			return err
+		}
+		return h.optionN(tok.Name, values)
+	}
+	value := tok.Value
+	if tok.HasValue {
+		var err error
+		if value, err = p.checkValue(tok.Name, t.kind, value); err != nil {
+			return err
+		}
+	}
+	// Within a group of short options, t.index still points to the group.
+	end := max(t.index, tok.Index+1)
+	switch t.kind.Base() {
+	case Property:
+		return h.property(tok.Name, value, args[tok.Index:end:end])
+	case Toggle:
+		var n int
+		st.toggles, n = addCount(st.toggles, tok.Name)
+		return h.toggle(tok.Name, n-1, args[tok.Index:end:end])
+	default:
+		return h.option(tok.Name, value, tok.HasValue, args[tok.Index:end:end])
+	}
+}
+
+// record appends tok to the ParseResult being recorded, if any.
+func (p *Parser) record(tok *Token) {
+	if p.result == nil {
+		return
+	}
+	p.result.Events = append(p.result.Events, *tok)
+	if tok.Kind == OptionToken {
+		p.result.Options = append(p.result.Options, Occurrence{
+			Name:     tok.Name,
+			Index:    tok.Index,
+			Value:    tok.Value,
+			HasValue: tok.HasValue,
+			Values:   tok.Values,
+		})
+	}
+}
+
+// counter is the number of occurrences of a Counter or Toggle option.
+type counter struct {
+	name string
+	n    int
+}
+
+// addCount counts an occurrence of name and returns the updated counters and
+// the number of occurrences so far.
+func addCount(counters []counter, name string) ([]counter, int) {
+	for i := range counters {
+		if counters[i].name == name {
+			counters[i].n++
+			return counters, counters[i].n
+		}
+	}
+	return append(counters, counter{name, 1}), 1
+}
+
+var shortNames, plusNames = func() (names, plus [256]string) {
+	for i := range names {
+		names[i] = string([]byte{'-', byte(i)})
+		plus[i] = string([]byte{'+', byte(i)})
+	}
+	return
+}()
+
+func (p *Parser) parse(opts Options, args []string, flags int) ([]string, int, error) {
+	if p.Metrics == nil {
+		return p.scan(opts, args, flags)
+	}
+	p.stats = Stats{}
+	start := time.Now()
+	positional, n, err := p.scan(opts, args, flags)
+	p.stats.Positional = n
+	p.stats.Duration = time.Since(start)
+	p.Metrics.ObserveParse(p.stats, err)
+	return positional, n, err
+}
+
+func (p *Parser) scan(opts Options, args []string, flags int) ([]string, int, error) {
+	positional := p.positional[:0]
+	var indexed []IndexedArg
+	var st optionState
+	var npos, nbefore int
+	var ddash bool
+	h := newHandlers(opts)
+	if p.ctx != nil {
+		h.ctx = p.ctx
+	}
+	p.warnings = nil
+	p.errs = nil
+	p.bounds = p.bounds[:0]
+	p.groups = nil
+	p.unknown = nil
+	p.stop = -1
+	if p.ResponseFiles {
+		var err error
+		if args, err = expandResponseFiles(args); err != nil {
+			return nil, 0, err
+		}
+	}
+	if h.pre != nil {
+		if err := h.pre.PreParse(args); err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+
+	if p.EarlyExit {
+		flags |= earlyExit
+	}
+	if p.NoDDash {
+		flags |= noDDash
+	}
+	if p.StrictOptional {
+		flags |= strictOptional
+	}
+	if p.ShortEquals {
+		flags |= shortEquals
+	}
+	if p.BoolValues {
+		flags |= boolValues
+	}
+	if p.DigitOptions {
+		flags |= digitOptions
+	}
+	if p.PlusOptions {
+		flags |= plusOptions
+	}
+	if p.SingleDashLong {
+		flags |= singleDashLong
+	}
+	if p.Passthrough {
+		flags |= passthrough
+	}
+	if p.StopAtUnknown {
+		flags |= stopAtUnknown
+	}
+	if p.IgnoreCase {
+		flags |= ignoreCase
+	}
+	if p.Abbreviations {
+		flags |= abbreviations
+	}
+	if p.NegativeNumbers {
+		flags |= negativeNumbers
+	}
+	if p.DDashGroups {
+		flags |= ddashGroups
+	}
+	if p.NoBundling {
+		flags |= noBundling
+	}
+	if p.LongOnly {
+		flags |= longOnly
+	}
+	t := Tokenizer{opts: opts, args: args, flags: flags, prefixes: p.Prefixes}
+	if p.Logger != nil {
+		t.opts = kindTracer{opts, p.Logger}
+	}
+	for t.Next() || p.resume(&t) {
+		if p.ctx != nil {
+			if err := p.ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+		}
+		tok := &t.tok
+		var err error
+		var start time.Time
+		if p.Metrics != nil {
+			start = time.Now()
+		}
+		switch tok.Kind {
+		case OptionToken:
+			err = p.optionToken(&h, &t, &st, args)
+		case DDashToken:
+			ddash = true
+			nbefore = len(positional)
+			if p.stop < 0 {
+				p.stop = tok.Index + 1
+			}
+			if p.KeepDDash {
+				if h.aopts != nil {
+					err = h.aopts.Arg(npos, "--", false)
+				}
+				if flags&noCollect == 0 {
+					if cap(positional) == 0 {
+						positional = make([]string, 0, len(args)-tok.Index)
 					}
+					positional = append(positional, "--")
 				}
+				npos++
 			}
-			if aopts, ok := opts.(OptionsWithArgs); ok {
-				if err := aopts.Args(positional, args[1:]); err != nil {
-					return nil, err
-				}
+		case PositionalToken:
+			if tok.Unknown && flags&knownOnly != 0 {
+				p.unknown = append(p.unknown, tok.Value)
+				break
 			}
-			return append(positional, args[1:]...), nil
-		case !strings.HasPrefix(args[0], "-"), args[0] == "-", args[0] == "--", exited:
-			if aopts, ok := opts.(OptionsWithArg); ok {
-				if err := aopts.Arg(len(positional), args[0], false); err != nil {
-					return nil, err
-				}
+			if flags&ddashGroups != 0 && tok.AfterDDash && tok.Value == "--" {
+				p.bounds = append(p.bounds, len(positional))
+				break
 			}
-			positional = append(positional, args[0])
-			args = args[1:]
-			if flags&earlyExit != 0 {
-				exited = true
+			if (flags&earlyExit != 0 || t.exited) && p.stop < 0 && !tok.Unknown {
+				p.stop = tok.Index
 			}
-			continue
-		case strings.HasPrefix(args[0], "--"):
-			name, value, hasValue = strings.Cut(args[0], "=")
-			switch opts.Kind(name) {
-			case Required:
-				if hasValue {
-					args = args[1:]
-				} else if len(args) < 2 {
-					return nil, Errorf("option %s requires an argument", name)
-				} else {
-					value = args[1]
-					hasValue = true
-					args = args[2:]
-				}
-			case Optional:
-				args = args[1:]
-			case Boolean:
-				if hasValue {
-					return nil, Errorf("option %s takes no argument", name)
-				}
-				args = args[1:]
-			case TakeTwoArgs:
-				if hasValue {
-					return nil, Errorf("option %s takes 2 arguments; %s=VALUE form is not permitted", name, name)
-				} else if len(args) < 3 {
-					return nil, Errorf("option %s requires 2 arguments", name)
-				}
-				if nopts, ok := opts.(OptionsWithOptionN); ok {
-					if err := nopts.OptionN(name, args[1:3]); err != nil {
-						return nil, Errorf("option %s: %w", name, err)
-					}
-				} else {
-					panic("Kind() returns TakeTwoArgs but OptionN method is not implemented")
-				}
-				args = args[3:]
-				continue
-			default:
-				return nil, Errorf("unknown option %q", name)
+			if h.aopts != nil {
+				err = h.aopts.Arg(npos, tok.Value, tok.AfterDDash)
 			}
-		case len(args[0]) > 2:
-			name = args[0][:2]
-			switch opts.Kind(name) {
-			case Required, Optional:
-				value = args[0][2:]
-				hasValue = true
-				args = args[1:]
-			case Boolean:
-				if args[0][2] == '-' {
-					return nil, Errorf("invalid option '-'")
-				}
-				args[0] = "-" + args[0][2:]
-			case TakeTwoArgs:
-				value = args[0][2:]
-				if len(args) < 2 {
-					return nil, Errorf("option %s requires 2 arguments", name)
+			if flags&noCollect == 0 {
+				if cap(positional) == 0 {
+					// The remaining arguments bound the number of positional
+					// arguments, so a single allocation suffices.
+					positional = make([]string, 0, len(args)-tok.Index)
 				}
-				values := []string{args[0][2:], args[1]}
-				if nopts, ok := opts.(OptionsWithOptionN); ok {
-					if err := nopts.OptionN(name, values); err != nil {
-						return nil, Errorf("option %s: %w", name, err)
-					}
-				} else {
-					panic("Kind() returns TakeTwoArgs but OptionN method is not implemented")
+				positional = append(positional, tok.Value)
+				if h.iopts != nil {
+					indexed = append(indexed, IndexedArg{tok.Index, tok.Value})
 				}
-				args = args[2:]
-				continue
-			default:
-				return nil, Errorf("unknown option %q", name)
 			}
-		default:
-			name = args[0]
-			switch opts.Kind(name) {
-			case Required:
-				if len(args) == 1 {
-					return nil, Errorf("option %s requires an argument", name)
-				}
-				value = args[1]
-				hasValue = true
-				args = args[2:]
-			case Boolean, Optional:
-				args = args[1:]
-			case TakeTwoArgs:
-				if len(args) < 3 {
-					return nil, Errorf("option %s requires 2 arguments", name)
-				}
-				values := []string{args[1], args[2]}
-				if nopts, ok := opts.(OptionsWithOptionN); ok {
-					if err := nopts.OptionN(name, values); err != nil {
-						return nil, Errorf("option %s: %w", name, err)
-					}
-				} else {
-					panic("Kind() returns TakeTwoArgs but OptionN method is not implemented")
-				}
-				args = args[3:]
-				continue
-			default:
-				return nil, Errorf("unknown option %q", name)
+			npos++
+		}
+		if p.Metrics != nil {
+			p.observe(tok, start, err)
+		}
+		if p.Logger != nil {
+			p.traceToken(tok, tok.Kind == OptionToken && isSecret(opts, tok.Name), err)
+		}
+		if err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+		if err == nil && tok.Kind == OptionToken && t.kind&Deprecated != 0 {
+			p.warn(&h, deprecationWarning(opts, tok.Name))
+		}
+		if p.perm != nil {
+			p.perm.add(tok, args, t.index)
+		}
+		p.record(tok)
+	}
+	if p.stop < 0 {
+		p.stop = t.index
+	}
+	if t.err != nil {
+		if p.Logger != nil {
+			p.Logger.LogAttrs(context.Background(), slog.LevelDebug, "error", slog.Int("index", t.index), slog.Any("error", t.err))
+		}
+		if err := p.promptMissing(&h, &t, &st, args); err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+	for _, c := range st.counters {
+		err := h.count(c.name, c.n)
+		if p.Logger != nil {
+			p.Logger.LogAttrs(context.Background(), slog.LevelDebug, "count", slog.String("name", c.name), slog.Int("count", c.n), slog.Any("error", err))
+		}
+		if err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+	if flags&noCollect != 0 {
+		if h.post != nil {
+			if err := h.post.PostParse(nil); err != nil && !p.warn(&h, err) && !p.collect(err) {
+				return nil, 0, err
 			}
 		}
-		if err := opts.Option(name, value, hasValue); err == ErrUnknown {
-			return nil, Errorf("unknown option %q", name)
-		} else if err != nil {
-			return nil, Errorf("option %s: %w", name, err)
+		return nil, npos, p.collected()
+	}
+	p.positional = positional
+	if !ddash {
+		nbefore = len(positional)
+	}
+	before, after := positional[:nbefore:nbefore], slices.Clip(positional[nbefore:])
+	if !ddash {
+		after = nil
+	}
+	if flags&ddashGroups != 0 {
+		p.groups = append(make([][]string, 0, 2+len(p.bounds)), before)
+		if ddash {
+			start := nbefore
+			if p.KeepDDash {
+				start++
+			}
+			for _, end := range p.bounds {
+				p.groups = append(p.groups, positional[start:end:end])
+				start = end
+			}
+			p.groups = append(p.groups, slices.Clip(positional[start:]))
 		}
 	}
-	if aopts, ok := opts.(OptionsWithArgs); ok {
-		if err := aopts.Args(positional, nil); err != nil {
-			return nil, err
+	if p.result != nil {
+		p.result.Before, p.result.After = before, after
+	}
+	if h.sopts != nil {
+		err := h.sopts.Args(before, after)
+		if p.Logger != nil {
+			p.Logger.LogAttrs(context.Background(), slog.LevelDebug, "args", slog.Any("before", before), slog.Any("after", after), slog.Any("error", err))
+		}
+		if err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+	if h.iopts != nil {
+		ibefore, iafter := indexed[:nbefore:nbefore], slices.Clip(indexed[nbefore:])
+		if !ddash {
+			iafter = nil
 		}
+		if err := h.iopts.ArgsIndexed(ibefore, iafter); err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+	if h.post != nil {
+		if err := h.post.PostParse(slices.Clip(positional)); err != nil && !p.warn(&h, err) && !p.collect(err) {
+			return nil, 0, err
+		}
+	}
+	if err := p.collected(); err != nil {
+		return nil, 0, err
 	}
-	return positional, nil
+	return positional, npos, nil
 }
 
 // Parse parses command-line options from the argument list, which should
 // not include the command name. Interleaving of options and non-options is allowed.
 // Returns the positional arguments.
 func Parse(opts Options, args []string) ([]string, error) {
-	return parse(opts, args, 0)
+	return new(Parser).Parse(opts, args)
 }
 
 // ParsePOSIX parses command-line options from the argument list, which should
 // not include the command name. It stop parsing at the first non-option argument.
 // Returns the positional arguments.
 func ParsePOSIX(opts Options, args []string) ([]string, error) {
-	return parse(opts, args, earlyExit)
+	return new(Parser).ParsePOSIX(opts, args)
+}
+
+// ParseStrictPOSIX is like ParsePOSIX, but follows the Utility Syntax
+// Guidelines of POSIX strictly: only short options are parsed, and an
+// argument starting with -- other than the -- itself is rejected with
+// CodeInvalidOption.
+func ParseStrictPOSIX(opts Options, args []string) ([]string, error) {
+	return new(Parser).ParseStrictPOSIX(opts, args)
 }
 
 // ParseS parses command-line options from the argument list, which should not
@@ -255,9 +926,59 @@ func ParsePOSIX(opts Options, args []string) ([]string, error) {
 // Returns the positional arguments.
 // If no positional arguments was provided, it will return ErrNoSubcommand.
 func ParseS(opts Options, args []string) ([]string, error) {
-	args, err := parse(opts, args, earlyExit|noDDash)
-	if err == nil && len(args) == 0 {
-		return nil, ErrNoSubcommand
+	return new(Parser).ParseS(opts, args)
+}
+
+// ParseKnown is like Parse, but returns the unknown options separately from
+// the positional arguments instead of failing, like parse_known_args of
+// Python's argparse, e.g. for a plugin to parse them. They are returned in
+// their original form with any value attached with =; an unknown short option
+// in a group comes with the rest of the group, as -xyz for -x in -axyz.
+// Values given as separate arguments are positional arguments, since the
+// parser cannot tell whether an unknown option takes them.
+func ParseKnown(opts Options, args []string) (positional, unknown []string, err error) {
+	return new(Parser).ParseKnown(opts, args)
+}
+
+// ParseContext is like Parse, but passes ctx to the OptionContext method and
+// the Resolvers, and stops with the error of ctx once ctx is done.
+func ParseContext(ctx context.Context, opts Options, args []string) ([]string, error) {
+	return new(Parser).ParseContext(ctx, opts, args)
+}
+
+// ParseStream is like Parse, but delivers the positional arguments only
+// through the Arg method, without accumulating them into a slice, so that
+// memory use does not grow with the number of positional arguments.
+// The Args method is not called even if opts implements OptionsWithArgs.
+// Returns the number of positional arguments.
+func ParseStream(opts OptionsWithArg, args []string) (int, error) {
+	_, n, err := new(Parser).parse(opts, args, noCollect)
+	return n, err
+}
+
+// SplitDDash splits args at every --, e.g. the positional arguments after
+// the first -- passed to the Args method, for command lines that layer
+// several pass-through sections such as "cmd FILES -- BUILD-ARGS -- RUN-ARGS".
+// The groups share storage with args and their capacities are clipped.
+// SplitDDash returns one more group than the number of -- in args.
+func SplitDDash(args []string) [][]string {
+	groups := make([][]string, 0, 1+countDDash(args))
+	start := 0
+	for i, arg := range args {
+		if arg == "--" {
+			groups = append(groups, args[start:i:i])
+			start = i + 1
+		}
+	}
+	return append(groups, slices.Clip(args[start:]))
+}
+
+func countDDash(args []string) int {
+	var n int
+	for _, arg := range args {
+		if arg == "--" {
+			n++
+		}
 	}
-	return args, err
+	return n
 }
@@ -4,9 +4,12 @@
 package options
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -407,3 +410,853 @@ func TestError(t *testing.T) {
 		t.Errorf("werr is not strconv.ErrSyntax")
 	}
 }
+
+type boolOptions struct {
+	Count int
+}
+
+func (opts *boolOptions) Kind(name string) Kind {
+	switch name {
+	case "-a", "-b", "-c", "-d", "-e", "-f":
+		return Boolean
+	default:
+		return Unknown
+	}
+}
+
+func (opts *boolOptions) Option(name, value string, hasValue bool) error {
+	opts.Count++
+	return nil
+}
+
+func TestParseDoesNotModifyArgs(t *testing.T) {
+	args := []string{"-abc", "-abrval", "-as", "k", "v"}
+	if _, err := Parse(&TestOptions{}, args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"-abc", "-abrval", "-as", "k", "v"})
+}
+
+func TestBundleAllocs(t *testing.T) {
+	opts := &boolOptions{}
+	args := []string{"-abcdef", "-fedcba", "-a", "-bc"}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := Parse(opts, args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkParseBundle(b *testing.B) {
+	opts := &boolOptions{}
+	args := []string{"-abcdef", "-fedcba", "-abcdef", "-fedcba"}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := Parse(opts, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseBundleWithValue(b *testing.B) {
+	opts := &TestOptions{}
+	args := []string{"-abcrvalue", "-abo", "-abcs", "k", "v"}
+	b.ReportAllocs()
+	for range b.N {
+		opts.OptionHistory = opts.OptionHistory[:0]
+		opts.OptionNHistory = opts.OptionNHistory[:0]
+		if _, err := Parse(opts, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPositionalAllocs(t *testing.T) {
+	args := make([]string, 0, 1000)
+	for i := range 500 {
+		args = append(args, "-a", strconv.Itoa(i))
+	}
+	args = append(args, "--", "x", "y")
+	opts := &boolOptions{}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := Parse(opts, args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("expected 1 allocation, got %v", allocs)
+	}
+}
+
+func BenchmarkParsePositional(b *testing.B) {
+	args := make([]string, 0, 10000)
+	for i := range 10000 {
+		args = append(args, strconv.Itoa(i))
+	}
+	opts := &boolOptions{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := Parse(opts, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	opts := &TestOptions{}
+	n, err := ParseStream(opts, []string{"val1", "-a", "val2", "--", "-b", "val3"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4, got %v", n)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}})
+	CompareSlice(t, "ArgHistory", opts.ArgHistory, []ArgCall{
+		{Index: 0, Value: "val1", AfterDDash: false},
+		{Index: 1, Value: "val2", AfterDDash: false},
+		{Index: 2, Value: "-b", AfterDDash: true},
+		{Index: 3, Value: "val3", AfterDDash: true},
+	})
+	if opts.Before != nil || opts.After != nil {
+		t.Errorf("Args must not be called")
+	}
+
+	args := make([]string, 10000)
+	for i := range args {
+		args[i] = strconv.Itoa(i)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := ParseStream(&countArgs{}, args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most 1 allocation, got %v", allocs)
+	}
+}
+
+type countArgs struct {
+	boolOptions
+	N int
+}
+
+func (opts *countArgs) Arg(index int, value string, afterDDash bool) error {
+	opts.N++
+	return nil
+}
+
+func BenchmarkParseStreamMixed(b *testing.B) {
+	args := make([]string, 0, 10000)
+	for i := range 5000 {
+		args = append(args, "-a", strconv.Itoa(i))
+	}
+	opts := &countArgs{}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := ParseStream(opts, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type appendArgs struct {
+	boolOptions
+	Before, After []string
+}
+
+func (opts *appendArgs) Args(before, after []string) error {
+	opts.Before = append(before, "x")
+	opts.After = append(after, "y")
+	return nil
+}
+
+func TestArgsAppendDoesNotCorrupt(t *testing.T) {
+	input := make([]string, 0, 10)
+	input = append(input, "a", "b", "--", "c", "d")
+	opts := &appendArgs{}
+	args, err := Parse(opts, input)
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"a", "b", "c", "d"})
+	CompareSlice(t, "before", opts.Before, []string{"a", "b", "x"})
+	CompareSlice(t, "after", opts.After, []string{"c", "d", "y"})
+	CompareSlice(t, "input", input[:cap(input)], []string{"a", "b", "--", "c", "d", "", "", "", "", ""})
+}
+
+type countStringer struct{ N int }
+
+func (s *countStringer) String() string {
+	s.N++
+	return "value"
+}
+
+func TestErrorfLazy(t *testing.T) {
+	s := &countStringer{}
+	err := Errorf("option %s: %w", s, strconv.ErrRange)
+	if !errors.Is(err, ErrCmdline) || !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("errors.Is() = false, want true")
+	}
+	if s.N != 0 {
+		t.Errorf("String() called %d times before Error(), want 0", s.N)
+	}
+	if got, want := err.Error(), "option value: value out of range"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorfUnwrap(t *testing.T) {
+	tests := []struct {
+		format string
+		args   []any
+		want   error
+	}{
+		{"%w", []any{strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%d%% %s: %w", []any{1, "a", strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%*d %w", []any{3, 1, strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%[2]w %[1]s", []any{"a", strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%v: %w", []any{strconv.ErrRange, strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%-8.3s%%w %[3]w", []any{"abc", 1, strconv.ErrSyntax}, strconv.ErrSyntax},
+		{"%v", []any{strconv.ErrSyntax}, nil},
+		{"%w %w", []any{strconv.ErrSyntax, strconv.ErrRange}, nil},
+		{"%w", []any{"not an error"}, nil},
+	}
+	for _, tt := range tests {
+		if got := errors.Unwrap(Errorf(tt.format, tt.args...)); got != tt.want {
+			t.Errorf("Unwrap(Errorf(%q)) = %v, want %v", tt.format, got, tt.want)
+		}
+		if got, want := errors.Unwrap(fmt.Errorf(tt.format, tt.args...)), tt.want; got != want {
+			t.Errorf("Unwrap(fmt.Errorf(%q)) = %v, want %v", tt.format, got, want)
+		}
+	}
+}
+
+func BenchmarkErrorf(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		if err := Errorf("option %s: %w", "-a", strconv.ErrSyntax); !errors.Is(err, ErrCmdline) {
+			b.Fatal("not ErrCmdline")
+		}
+	}
+}
+
+type rawArgs []string
+
+func (l rawArgs) Equal(r rawArgs) bool {
+	return slices.Equal(l, r)
+}
+
+type rawOptions struct {
+	TestOptions
+	Raw []rawArgs
+}
+
+func (opts *rawOptions) OptionRaw(name, value string, hasValue bool, raw []string) error {
+	opts.Raw = append(opts.Raw, raw)
+	return opts.Option(name, value, hasValue)
+}
+
+func TestOptionRaw(t *testing.T) {
+	opts := &rawOptions{}
+	if _, err := Parse(opts, []string{"-abr5", "--number=NaN", "x", "-r", "5", "--optional"}); err == nil {
+		t.Fatalf("Parse(): expected error")
+	}
+	opts = &rawOptions{}
+	if _, err := Parse(opts, []string{"-abr5", "--number=1", "x", "-r", "5", "--optional"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSliceF(t, "raw", opts.Raw, []rawArgs{
+		{"-abr5"}, {"-abr5"}, {"-abr5"}, {"--number=1"}, {"-r", "5"}, {"--optional"},
+	})
+}
+
+type lifecycleOptions struct {
+	TestOptions
+	Events []string
+}
+
+func (opts *lifecycleOptions) PreParse(args []string) error {
+	opts.Events = append(opts.Events, "pre "+strconv.Itoa(len(args)))
+	return nil
+}
+
+func (opts *lifecycleOptions) Option(name, value string, hasValue bool) error {
+	opts.Events = append(opts.Events, "option "+name)
+	return nil
+}
+
+func (opts *lifecycleOptions) Args(before, after []string) error {
+	opts.Events = append(opts.Events, "args")
+	return nil
+}
+
+func (opts *lifecycleOptions) PostParse(positional []string) error {
+	opts.Events = append(opts.Events, "post "+strconv.Itoa(len(positional)))
+	if len(positional) > 1 {
+		return Errorf("too many arguments")
+	}
+	return nil
+}
+
+func TestLifecycle(t *testing.T) {
+	opts := &lifecycleOptions{}
+	if _, err := Parse(opts, []string{"-a", "x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "events", opts.Events, []string{"pre 2", "option -a", "args", "post 1"})
+
+	opts = &lifecycleOptions{}
+	if _, err := Parse(opts, []string{"x", "y"}); err == nil || err.Error() != "too many arguments" {
+		t.Errorf("Parse(): expected PostParse error, but got %v", err)
+	}
+}
+
+type indexedOptions struct {
+	TestOptions
+	IndexedBefore []IndexedArg
+	IndexedAfter  []IndexedArg
+}
+
+func (opts *indexedOptions) ArgsIndexed(before, after []IndexedArg) error {
+	opts.IndexedBefore = before
+	opts.IndexedAfter = after
+	return nil
+}
+
+func TestArgsIndexed(t *testing.T) {
+	opts := &indexedOptions{}
+	if _, err := Parse(opts, []string{"x", "-r", "y", "-ab", "z", "--", "-c"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "Before", opts.Before, []string{"x", "z"})
+	CompareSlice(t, "IndexedBefore", opts.IndexedBefore, []IndexedArg{{0, "x"}, {4, "z"}})
+	CompareSlice(t, "IndexedAfter", opts.IndexedAfter, []IndexedArg{{6, "-c"}})
+
+	opts = &indexedOptions{}
+	if _, err := Parse(opts, []string{"-a", "x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "IndexedBefore", opts.IndexedBefore, []IndexedArg{{1, "x"}})
+	if opts.IndexedAfter != nil {
+		t.Errorf("IndexedAfter: expected nil, got %v", opts.IndexedAfter)
+	}
+}
+
+func TestSplitDDash(t *testing.T) {
+	opts := &TestOptions{}
+	if _, err := Parse(opts, []string{"x", "--", "-a", "--", "--", "y"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	groups := SplitDDash(opts.After)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %q", groups)
+	}
+	CompareSlice(t, "groups[0]", groups[0], []string{"-a"})
+	CompareSlice(t, "groups[1]", groups[1], []string{})
+	CompareSlice(t, "groups[2]", groups[2], []string{"y"})
+	if cap(groups[0]) != 1 {
+		t.Errorf("capacity is not clipped: %d", cap(groups[0]))
+	}
+
+	if groups := SplitDDash(nil); len(groups) != 1 || len(groups[0]) != 0 {
+		t.Errorf("SplitDDash(nil) = %q, want one empty group", groups)
+	}
+}
+
+type contextKey struct{}
+
+type contextOptions struct {
+	TestOptions
+	cancel context.CancelFunc
+	Seen   []any
+}
+
+func (opts *contextOptions) OptionContext(ctx context.Context, name, value string, hasValue bool) error {
+	opts.Seen = append(opts.Seen, ctx.Value(contextKey{}))
+	if name == "-c" {
+		opts.cancel()
+	}
+	return opts.TestOptions.Option(name, value, hasValue)
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "v"))
+	defer cancel()
+	opts := &contextOptions{cancel: cancel}
+	if _, err := ParseContext(ctx, opts, []string{"-a", "x", "-b"}); err != nil {
+		t.Fatalf("ParseContext(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "Seen", opts.Seen, []any{"v", "v"})
+
+	_, err := ParseContext(ctx, opts, []string{"-c", "-a"})
+	if err != context.Canceled {
+		t.Errorf("ParseContext(): expected context.Canceled, got %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory[2:], []OptionCall{{"-c", "", false}})
+
+	opts = &contextOptions{}
+	if _, err := Parse(opts, []string{"-a"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "Seen", opts.Seen, []any{nil})
+}
+
+type nargsOptions struct {
+	TestOptions
+}
+
+func (opts *nargsOptions) Kind(name string) Kind {
+	switch name {
+	case "--rect", "-R":
+		return TakeNArgs(4)
+	case "--one", "-1":
+		return TakeNArgs(1)
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestTakeNArgs(t *testing.T) {
+	for kind, n := range map[Kind]int{Boolean: 0, Required: 1, Optional: 1, TakeTwoArgs: 2, TakeNArgs(1): 1, TakeNArgs(4): 4} {
+		if kind.NArgs() != n {
+			t.Errorf("%v.NArgs() = %d, want %d", kind, kind.NArgs(), n)
+		}
+	}
+	if TakeNArgs(4).Base() != TakeTwoArgs {
+		t.Errorf("TakeNArgs(4).Base() = %v, want TakeTwoArgs", TakeNArgs(4).Base())
+	}
+
+	opts := &nargsOptions{}
+	args, err := Parse(opts, []string{"--rect", "1", "2", "3", "4", "x", "-aR5", "6", "7", "8", "--one", "a", "-1b"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{
+		{"--rect", []string{"1", "2", "3", "4"}},
+		{"-R", []string{"5", "6", "7", "8"}},
+		{"--one", []string{"a"}},
+		{"-1", []string{"b"}},
+	})
+
+	for args, msg := range map[string]string{
+		"--rect 1 2 3": "option --rect requires 4 arguments",
+		"-R1 2 3":      "option -R requires 4 arguments",
+		"--rect=1":     "option --rect takes 4 arguments; --rect=VALUE form is not permitted",
+		"--one":        "option --one requires an argument",
+	} {
+		_, err := Parse(&nargsOptions{}, strings.Fields(args))
+		if err == nil || err.Error() != msg {
+			t.Errorf("%s: expected %q, got %v", args, msg, err)
+		}
+	}
+}
+
+type counterOptions struct {
+	TestOptions
+}
+
+func (opts *counterOptions) Kind(name string) Kind {
+	switch name {
+	case "-v", "--verbose", "-q":
+		return Counter
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+type countOptions struct {
+	counterOptions
+	Counts map[string]int
+}
+
+func (opts *countOptions) OptionCount(name string, count int) error {
+	if name == "-q" {
+		return Errorf("too quiet")
+	}
+	opts.Counts[name] += count
+	return nil
+}
+
+func TestCounter(t *testing.T) {
+	opts := &countOptions{Counts: map[string]int{}}
+	args, err := Parse(opts, []string{"-vvav", "x", "--verbose", "-v"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	if opts.Counts["-v"] != 4 || opts.Counts["--verbose"] != 1 {
+		t.Errorf("Counts = %v, want -v:4 --verbose:1", opts.Counts)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"-a", "", false}})
+
+	if _, err := Parse(opts, []string{"-q"}); err == nil || err.Error() != "option -q: too quiet" {
+		t.Errorf("Parse(-q): expected %q, got %v", "option -q: too quiet", err)
+	}
+	if _, err := Parse(opts, []string{"--verbose=2"}); err == nil || err.Error() != "option --verbose takes no argument" {
+		t.Errorf("Parse(--verbose=2): unexpected error: %v", err)
+	}
+
+	fallback := &counterOptions{}
+	if _, err := Parse(fallback, []string{"-vv", "--verbose", "-v"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", fallback.OptionHistory, []OptionCall{
+		{"-v", "3", true},
+		{"--verbose", "1", true},
+	})
+}
+
+type restOptions struct {
+	TestOptions
+}
+
+func (opts *restOptions) Kind(name string) Kind {
+	switch name {
+	case "--exec", "-e":
+		return Rest
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestRest(t *testing.T) {
+	opts := &restOptions{}
+	args, err := Parse(opts, []string{"x", "-a", "--exec", "cmd", "-b", "--", "y"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"-a", "", false}})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{
+		{"--exec", []string{"cmd", "-b", "--", "y"}},
+	})
+
+	opts = &restOptions{}
+	if _, err := Parse(opts, []string{"-aecmd", "x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{{"-e", []string{"cmd", "x"}}})
+
+	opts = &restOptions{}
+	if _, err := Parse(opts, []string{"-e"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{{"-e", []string{}}})
+
+	msg := "option --exec takes the remaining arguments; --exec=VALUE form is not permitted"
+	if _, err := Parse(&restOptions{}, []string{"--exec=cmd"}); err == nil || err.Error() != msg {
+		t.Errorf("expected %q, got %v", msg, err)
+	}
+}
+
+type terminatedOptions struct {
+	TestOptions
+}
+
+func (opts *terminatedOptions) Kind(name string) Kind {
+	switch name {
+	case "--exec", "-x":
+		return TerminatedBy(";", "+")
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestTerminatedBy(t *testing.T) {
+	if k := TerminatedBy(";", "+"); k != TerminatedBy(";", "+") || k.Base() != Terminated {
+		t.Errorf("TerminatedBy(\";\", \"+\") = %v, want an interned Terminated Kind", k)
+	}
+	CompareSlice(t, "Terminators", TerminatedBy(";").Terminators(), []string{";"})
+	CompareSlice(t, "Terminators", Required.Terminators(), nil)
+
+	opts := &terminatedOptions{}
+	args, err := Parse(opts, []string{"--exec", "rm", "{}", ";", "x", "-axecho", "+", "-x;", "-a"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{
+		{"--exec", []string{"rm", "{}", ";"}},
+		{"-x", []string{"echo", "+"}},
+		{"-x", []string{";"}},
+	})
+
+	for args, msg := range map[string]string{
+		"--exec rm {}":  "option --exec requires arguments terminated by ';' or '+'",
+		"-xrm":          "option -x requires arguments terminated by ';' or '+'",
+		"--exec=rm ; x": "option --exec takes arguments up to ';' or '+'; --exec=VALUE form is not permitted",
+	} {
+		_, err := Parse(&terminatedOptions{}, strings.Fields(args))
+		if err == nil || err.Error() != msg {
+			t.Errorf("%s: expected %q, got %v", args, msg, err)
+		}
+	}
+}
+
+type onceOptions struct {
+	TestOptions
+}
+
+func (opts *onceOptions) Kind(name string) Kind {
+	switch name {
+	case "--output", "-O":
+		return Required | Once
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestOnce(t *testing.T) {
+	if k := Required | Once; k.Base() != Required || k.String() != "Required|Once" {
+		t.Errorf("Required|Once: Base() = %v, String() = %q", k.Base(), k.String())
+	}
+	if k := TakeNArgs(3) | Once; k.NArgs() != 3 || k.String() != "TakeNArgs(3)|Once" {
+		t.Errorf("TakeNArgs(3)|Once: NArgs() = %d, String() = %q", k.NArgs(), k.String())
+	}
+
+	opts := &onceOptions{}
+	if _, err := Parse(opts, []string{"--output", "a", "-O", "b", "-aa"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	_, err := Parse(&onceOptions{}, []string{"--output=a", "x", "--output", "b"})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeRepeatedOption || err.Error() != "option --output specified multiple times" {
+		t.Errorf("expected CodeRepeatedOption, got %v", err)
+	}
+}
+
+type propertyOptions struct {
+	TestOptions
+	Properties []OptionCall
+}
+
+func (opts *propertyOptions) Kind(name string) Kind {
+	switch name {
+	case "-D", "--define":
+		return Property
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func (opts *propertyOptions) OptionProperty(name, key, value string, hasValue bool) error {
+	opts.Properties = append(opts.Properties, OptionCall{name + " " + key, value, hasValue})
+	return nil
+}
+
+func TestProperty(t *testing.T) {
+	opts := &propertyOptions{}
+	args, err := Parse(opts, []string{"-Dfoo=bar", "-aDbaz", "--define", "k=v=w", "--define=x=", "x", "-D", "abc=1"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "Properties", opts.Properties, []OptionCall{
+		{"-D foo", "bar", true}, {"-D baz", "", false}, {"--define k", "v=w", true}, {"--define x", "", true}, {"-D abc", "1", true},
+	})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"-a", "", false}})
+
+	var e *Error
+	if _, err := Parse(&propertyOptions{}, []string{"-D=bar"}); !errors.As(err, &e) || e.Code != CodeInvalidValue {
+		t.Errorf("expected CodeInvalidValue, got %v", err)
+	}
+
+	spec := &Spec{Name: "x", Options: []*OptionSpec{{Names: []string{"-D"}, Kind: Property}}}
+	if _, err := Parse(spec, []string{"-Dfoo=bar", "-Dbaz"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "Values", spec.Lookup("-D").Values(), []string{"foo=bar", "baz"})
+}
+
+type prefixOptions struct {
+	TestOptions
+}
+
+func (opts *prefixOptions) Kind(name string) Kind {
+	switch {
+	case name == "-W":
+		return Boolean | Prefix
+	case name == "-f":
+		return Optional | Prefix
+	case strings.HasPrefix(name, "--warn-"):
+		return Boolean
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestPrefix(t *testing.T) {
+	opts := &prefixOptions{}
+	args, err := Parse(opts, []string{"-Wno-unused", "-aWall", "-fcolor=always", "-f", "--warn-shadow", "x", "-W"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-Wno-unused", "", false}, {"-a", "", false}, {"-Wall", "", false}, {"-fcolor", "always", true},
+		{"-f", "", false}, {"--warn-shadow", "", false}, {"-W", "", false},
+	})
+	if _, err := Parse(&prefixOptions{}, []string{"-Wall=1"}); err == nil || err.Error() != "option -Wall takes no argument" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if s := (Required | Prefix).String(); s != "Required|Prefix" {
+		t.Errorf("String() = %q, want %q", s, "Required|Prefix")
+	}
+
+	var names []string
+	spec := &Spec{Name: "cc", Options: []*OptionSpec{
+		{Names: []string{"-W"}, Kind: Boolean | Prefix, Func: func(name string, _ []string) error {
+			names = append(names, name)
+			return nil
+		}},
+		{Names: []string{"--warn-"}, Kind: Optional | Prefix},
+		{Names: []string{"--warn-error"}, Kind: Required},
+	}}
+	if _, err := Parse(spec, []string{"-Wall", "--warn-shadow=1", "--warn-error", "x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "names", names, []string{"-Wall"})
+	CompareSlice(t, "Values", spec.Lookup("--warn-").Values(), []string{"1"})
+	CompareSlice(t, "Values", spec.Lookup("--warn-error").Values(), []string{"x"})
+}
+
+type dashValueOptions struct {
+	TestOptions
+}
+
+func (opts *dashValueOptions) Kind(name string) Kind {
+	switch name {
+	case "--offset":
+		return Required | DashValue
+	case "--output", "-O":
+		return Required | NoDashValue
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestDashValue(t *testing.T) {
+	opts := &dashValueOptions{}
+	if _, err := Parse(opts, []string{"--offset", "-5", "--output", "-", "--output=-x", "-O-y", "-aO", "z"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"--offset", "-5", true}, {"--output", "-", true}, {"--output", "-x", true}, {"-O", "-y", true}, {"-a", "", false}, {"-O", "z", true},
+	})
+
+	for _, args := range [][]string{{"--output", "--verbose"}, {"-aO", "-b"}} {
+		_, err := Parse(&dashValueOptions{}, args)
+		var e *Error
+		if !errors.As(err, &e) || e.Code != CodeMissingArg || !strings.HasSuffix(err.Error(), "requires an argument (got what looks like an option)") {
+			t.Errorf("%q: unexpected error: %v", args, err)
+		}
+	}
+	if s := (Required | NoDashValue).String(); s != "Required|NoDashValue" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+type toggleOptions struct {
+	TestOptions
+}
+
+func (opts *toggleOptions) Kind(name string) Kind {
+	switch name {
+	case "-x", "+x":
+		return Toggle
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+type toggleCall struct {
+	Name  string
+	On    bool
+	Index int
+}
+
+type toggleHandler struct {
+	toggleOptions
+	Toggles []toggleCall
+}
+
+func (opts *toggleHandler) OptionToggle(name string, on bool, index int) error {
+	opts.Toggles = append(opts.Toggles, toggleCall{name, on, index})
+	return nil
+}
+
+func TestToggle(t *testing.T) {
+	opts := &toggleHandler{}
+	if _, err := Parse(opts, []string{"-xa", "-x", "-bx"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "Toggles", opts.Toggles, []toggleCall{{"-x", true, 0}, {"-x", false, 1}, {"-x", true, 2}})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"-a", "", false}, {"-b", "", false}})
+
+	fallback := &toggleOptions{}
+	if _, err := Parse(fallback, []string{"-x", "-x"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", fallback.OptionHistory, []OptionCall{{"-x", "true", true}, {"-x", "false", true}})
+
+	spec := &Spec{Name: "x", Options: []*OptionSpec{{Names: []string{"--color"}, Kind: Toggle}}}
+	for args, want := range map[string]int{"--color": 1, "--color --color": 0, "--color --color --color": 1} {
+		spec.Reset()
+		if _, err := Parse(spec, strings.Fields(args)); err != nil {
+			t.Fatalf("Parse(): unexpected error: %v", err)
+		}
+		if n := spec.Lookup("--color").Count(); n != want {
+			t.Errorf("%s: Count() = %d, want %d", args, n, want)
+		}
+	}
+}
+
+func TestParseKnown(t *testing.T) {
+	opts := &TestOptions{}
+	args, unknown, err := ParseKnown(opts, []string{"x", "--plugin=1", "-a", "-bxz", "--plugin-flag", "y", "--", "--z"})
+	if err != nil {
+		t.Fatalf("ParseKnown(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "y", "--z"})
+	CompareSlice(t, "unknown", unknown, []string{"--plugin=1", "-xz", "--plugin-flag"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}, {Name: "-b"}})
+	CompareSlice(t, "Before", opts.Before, []string{"x", "y"})
+
+	if _, _, err := ParseKnown(&TestOptions{}, []string{"-r"}); Code(err) != CodeMissingArg {
+		t.Errorf("ParseKnown(): expected %s, but got %v", CodeMissingArg, err)
+	}
+
+	var p Parser
+	if _, _, err := p.ParseKnown(&TestOptions{}, []string{"--plugin", "x"}); err != nil {
+		t.Fatalf("ParseKnown(): unexpected error: %v", err)
+	}
+	p.Reset()
+	if p.unknown != nil {
+		t.Errorf("unknown = %q after Reset, want nil", p.unknown)
+	}
+	_, unknown, err = p.ParseKnown(&TestOptions{}, []string{"-a", "--other"})
+	if err != nil {
+		t.Fatalf("ParseKnown(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "unknown", unknown, []string{"--other"})
+}
+
+func TestParseStrictPOSIX(t *testing.T) {
+	opts := &TestOptions{}
+	args, err := ParseStrictPOSIX(opts, []string{"-ab", "-rvalue", "-r", "v", "--", "x", "--boolean"})
+	if err != nil {
+		t.Fatalf("ParseStrictPOSIX(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "--boolean"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "-a"}, {Name: "-b"}, {Name: "-r", Value: "value", HasValue: true}, {Name: "-r", Value: "v", HasValue: true},
+	})
+
+	args, err = ParseStrictPOSIX(&TestOptions{}, []string{"-a", "x", "--boolean"})
+	if err != nil {
+		t.Fatalf("ParseStrictPOSIX(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "--boolean"})
+
+	_, err = ParseStrictPOSIX(&TestOptions{}, []string{"-a", "--boolean=1"})
+	if Code(err) != CodeInvalidOption || err.Error() != `invalid option "--boolean"; long options are not permitted` {
+		t.Errorf("ParseStrictPOSIX(): unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+// IsCmdlineError reports whether err is an options.ErrCmdline whose message
+// contains substr.
+func IsCmdlineError(err error, substr string) bool {
+	return errors.Is(err, options.ErrCmdline) && strings.Contains(err.Error(), substr)
+}
+
+// AssertCmdlineError reports an error to t unless err is an
+// options.ErrCmdline whose message contains wantSubstr.
+func AssertCmdlineError(t testing.TB, err error, wantSubstr string) {
+	t.Helper()
+	switch {
+	case err == nil:
+		t.Errorf("expected a command-line error containing %q, but got nil", wantSubstr)
+	case !errors.Is(err, options.ErrCmdline):
+		t.Errorf("expected a command-line error, but got %v", err)
+	case !strings.Contains(err.Error(), wantSubstr):
+		t.Errorf("expected a command-line error containing %q, but got %q", wantSubstr, err.Error())
+	}
+}
+
+// AssertErrorIs reports an error to t unless errors.Is(err, target).
+func AssertErrorIs(t testing.TB, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("expected %v, but got %v", target, err)
+	}
+}
+
+// AssertNoError reports a fatal error to t if err is not nil.
+func AssertNoError(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// AssertErrorCode reports an error to t unless err is an *options.Error
+// with the given code.
+func AssertErrorCode(t testing.TB, err error, code options.ErrorCode) {
+	t.Helper()
+	if got := options.Code(err); got != code {
+		t.Errorf("expected an error with code %s, but got %v (code %q)", code, err, got)
+	}
+}
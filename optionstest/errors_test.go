@@ -0,0 +1,34 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+func TestAssertCmdlineError(t *testing.T) {
+	_, err := options.Parse(&testOptions{}, []string{"--bogus"})
+	AssertCmdlineError(t, err, `unknown option "--bogus"`)
+	AssertErrorCode(t, err, options.CodeUnknownOption)
+	if !IsCmdlineError(err, "--bogus") {
+		t.Errorf("IsCmdlineError() = false, want true")
+	}
+	if IsCmdlineError(errors.New("--bogus"), "--bogus") {
+		t.Errorf("IsCmdlineError() = true for a non-command-line error")
+	}
+	if IsCmdlineError(nil, "") {
+		t.Errorf("IsCmdlineError(nil) = true")
+	}
+
+	werr := options.Errorf("option -a: %w", strconv.ErrSyntax)
+	AssertErrorIs(t, werr, strconv.ErrSyntax)
+	AssertErrorIs(t, werr, options.ErrCmdline)
+
+	_, err = options.Parse(&testOptions{}, []string{"-a"})
+	AssertNoError(t, err)
+}
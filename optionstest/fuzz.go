@@ -0,0 +1,160 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/cions/go-options"
+)
+
+// DecodeArgs decodes fuzzer input into an argument list. Arguments are
+// separated by NUL bytes.
+func DecodeArgs(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	var args []string
+	for _, arg := range bytes.Split(data, []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+// EncodeArgs encodes an argument list into fuzzer input for DecodeArgs.
+func EncodeArgs(args []string) []byte {
+	var buf bytes.Buffer
+	for i, arg := range args {
+		if i > 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(arg)
+	}
+	return buf.Bytes()
+}
+
+// Corpus returns seed inputs covering the option forms understood by the
+// parser, encoded with EncodeArgs.
+func Corpus() [][]byte {
+	seeds := [][]string{
+		{},
+		{"a", "b"},
+		{"-b", "--b", "x"},
+		{"-bcd", "-br", "val", "-rval"},
+		{"--r=val", "--r", "val", "--o", "--o=val"},
+		{"-t", "k", "v", "--t", "k", "v", "-tk", "v"},
+		{"x", "--", "-b", "--r"},
+		{"-", "--", "--"},
+		{"cmd", "-b", "sub", "--r=1"},
+		{"--unknown", "-z"},
+	}
+	var corpus [][]byte
+	for _, seed := range seeds {
+		corpus = append(corpus, EncodeArgs(seed))
+	}
+	return corpus
+}
+
+// Permissive is an Options accepting every option. The Kind of an option is
+// derived from its last byte: names ending with 'b', 'r', 'o', 't' and 'n'
+// are Boolean, Required, Optional, TakeTwoArgs and TakeNArgs(3)
+// respectively, names ending with 'z' are Unknown, and the others cycle
+// through the first four kinds.
+type Permissive struct {
+	NArgs  int
+	Before []string
+	After  []string
+}
+
+// Kind implements options.Options.
+func (p *Permissive) Kind(name string) options.Kind {
+	switch c := name[len(name)-1]; c {
+	case 'b':
+		return options.Boolean
+	case 'r':
+		return options.Required
+	case 'o':
+		return options.Optional
+	case 't':
+		return options.TakeTwoArgs
+	case 'n':
+		return options.TakeNArgs(3)
+	case 'z':
+		return options.Unknown
+	default:
+		return options.Boolean + options.Kind(c%4)
+	}
+}
+
+// Option implements options.Options.
+func (p *Permissive) Option(name, value string, hasValue bool) error {
+	return nil
+}
+
+// OptionN implements options.OptionsWithOptionN.
+func (p *Permissive) OptionN(name string, values []string) error {
+	if len(values) != 2 {
+		panic(fmt.Sprintf("OptionN(%q) called with %d values", name, len(values)))
+	}
+	return nil
+}
+
+// Arg implements options.OptionsWithArg.
+func (p *Permissive) Arg(index int, value string, afterDDash bool) error {
+	if index != p.NArgs {
+		panic(fmt.Sprintf("Arg() called with index %d, want %d", index, p.NArgs))
+	}
+	p.NArgs++
+	return nil
+}
+
+// Args implements options.OptionsWithArgs.
+func (p *Permissive) Args(before, after []string) error {
+	p.Before = before
+	p.After = after
+	return nil
+}
+
+// Fuzz is a fuzzing entry point in the go-fuzz and OSS-Fuzz convention. It
+// decodes data with DecodeArgs and parses it with Parse, ParsePOSIX and
+// ParseS against a Permissive, panicking if an invariant of the parser is
+// violated. It returns 1 if any parse succeeded and 0 otherwise.
+func Fuzz(data []byte) int {
+	return FuzzOptions(func() options.Options { return &Permissive{} }, data)
+}
+
+// FuzzOptions is like Fuzz, but parses against the Options returned by
+// newOpts, so that downstream projects can fuzz their own handlers. The
+// invariants involving Arg and Args are only checked for a Permissive.
+func FuzzOptions(newOpts func() options.Options, data []byte) int {
+	args := DecodeArgs(data)
+	input := slices.Clone(args)
+	result := 0
+	for _, parse := range []func(options.Options, []string) ([]string, error){
+		options.Parse,
+		options.ParsePOSIX,
+		options.ParseS,
+	} {
+		opts := newOpts()
+		positional, err := parse(opts, args)
+		if !slices.Equal(args, input) {
+			panic("parser modified the argument list")
+		}
+		if err != nil {
+			continue
+		}
+		result = 1
+		if p, ok := opts.(*Permissive); ok {
+			if p.NArgs != len(positional) {
+				panic(fmt.Sprintf("Arg() called %d times for %d positional arguments", p.NArgs, len(positional)))
+			}
+			if !slices.Equal(append(slices.Clip(p.Before), p.After...), positional) {
+				panic(fmt.Sprintf("Args(%q, %q) does not match %q", p.Before, p.After, positional))
+			}
+		}
+	}
+	return result
+}
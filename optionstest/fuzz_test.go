@@ -0,0 +1,26 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEncodeArgs(t *testing.T) {
+	for _, args := range [][]string{nil, {"a"}, {"-a", "", "b"}} {
+		if got := DecodeArgs(EncodeArgs(args)); !slices.Equal(got, args) {
+			t.Errorf("DecodeArgs(EncodeArgs(%q)) = %q", args, got)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range Corpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Fuzz(data)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"cmp"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cions/go-options"
+)
+
+// Generator generates random command lines following the grammar of a spec,
+// for property-based testing of programs and Options implementations. Rest
+// options are not generated, as they would take the rest of the command line.
+type Generator struct {
+	// Spec is the spec whose grammar the command lines follow.
+	Spec *options.Spec
+
+	// MaxOptions is the maximum number of optional options per command.
+	// If zero, 5 is used.
+	MaxOptions int
+
+	// MaxArgs is the maximum number of arguments for a variadic positional
+	// argument. If zero, 3 is used.
+	MaxArgs int
+}
+
+// Valid returns a random command line accepted by Spec, not including the
+// command name. All required options are specified.
+func (g *Generator) Valid(r *rand.Rand) []string {
+	var args []string
+	var chain []*options.Spec
+	cmd := g.Spec
+	for {
+		chain = append(chain, cmd)
+		for _, c := range chain {
+			for _, o := range c.Options {
+				if o.Required {
+					args = append(args, g.option(r, o)...)
+				}
+			}
+		}
+		var all []*options.OptionSpec
+		for _, c := range chain {
+			all = append(all, c.Options...)
+		}
+		if len(all) > 0 {
+			for range r.Intn(cmp.Or(g.MaxOptions, 5) + 1) {
+				args = append(args, g.option(r, all[r.Intn(len(all))])...)
+			}
+		}
+		if len(cmd.Commands) == 0 {
+			break
+		}
+		cmd = cmd.Commands[r.Intn(len(cmd.Commands))]
+		args = append(args, cmd.Name)
+	}
+
+	var positional []string
+	for _, a := range cmd.Positional {
+		n := 1
+		if a.Variadic {
+			n = r.Intn(cmp.Or(g.MaxArgs, 3) + 1)
+		}
+		for range n {
+			positional = append(positional, word(r, a.Choices))
+		}
+	}
+	if len(positional) > 0 && r.Intn(2) == 0 {
+		args = append(args, "--")
+	}
+	return append(args, positional...)
+}
+
+// Invalid returns a random command line that is rejected by Spec, made by
+// applying a small mutation to a valid one: an unknown option, an option
+// missing its argument, a value not in Choices, a value given to a Boolean
+// option, or an unknown subcommand.
+func (g *Generator) Invalid(r *rand.Rand) []string {
+	var mutations []func([]string) []string
+	mutations = append(mutations, func(args []string) []string {
+		return append([]string{"--no-such-option-" + strconv.Itoa(r.Intn(100))}, args...)
+	})
+	if len(g.Spec.Commands) > 0 {
+		mutations = append(mutations, func([]string) []string {
+			return []string{"no-such-command-" + strconv.Itoa(r.Intn(100))}
+		})
+	}
+	for _, o := range g.Spec.Options {
+		switch kind(o).Base() {
+		case options.Required, options.TakeTwoArgs, options.Terminated, options.Property:
+			mutations = append(mutations, func([]string) []string {
+				return []string{o.Names[0]}
+			})
+		case options.Boolean:
+			if long := o.Long(); long != "" {
+				mutations = append(mutations, func(args []string) []string {
+					return append([]string{long + "=x"}, args...)
+				})
+			}
+		}
+		if len(o.Choices) > 0 && kind(o).Base() != options.Boolean {
+			if long := o.Long(); long != "" {
+				mutations = append(mutations, func(args []string) []string {
+					return append([]string{long + "=" + strings.Join(o.Choices, "") + "x"}, args...)
+				})
+			}
+		}
+	}
+	return mutations[r.Intn(len(mutations))](g.Valid(r))
+}
+
+// Values returns a function for the Values field of testing/quick.Config
+// that fills every argument of the tested function with a valid command
+// line of type []string.
+func (g *Generator) Values() func([]reflect.Value, *rand.Rand) {
+	return func(values []reflect.Value, r *rand.Rand) {
+		for i := range values {
+			values[i] = reflect.ValueOf(g.Valid(r))
+		}
+	}
+}
+
+func (g *Generator) option(r *rand.Rand, o *options.OptionSpec) []string {
+	name := o.Names[r.Intn(len(o.Names))]
+	short := !strings.HasPrefix(name, "--")
+	switch kind(o).Base() {
+	case options.Required, options.Property:
+		value := word(r, o.Choices)
+		if kind(o).Base() == options.Property && len(o.Choices) == 0 {
+			value += "=" + word(r, nil)
+		}
+		if r.Intn(2) == 0 {
+			return []string{name, value}
+		} else if short {
+			return []string{name + value}
+		}
+		return []string{name + "=" + value}
+	case options.Optional:
+		if r.Intn(2) == 0 {
+			return []string{name}
+		} else if short {
+			return []string{name + word(r, o.Choices)}
+		}
+		return []string{name + "=" + word(r, o.Choices)}
+	case options.TakeTwoArgs:
+		args := []string{name}
+		for range kind(o).NArgs() {
+			args = append(args, word(r, nil))
+		}
+		return args
+	case options.Rest:
+		// It would take the rest of the command line.
+		return nil
+	case options.Terminated:
+		args := []string{name}
+		for range r.Intn(3) {
+			args = append(args, word(r, o.Choices))
+		}
+		return append(args, kind(o).Terminators()[0])
+	default:
+		return []string{name}
+	}
+}
+
+func kind(o *options.OptionSpec) options.Kind {
+	if o.Kind == options.Unknown {
+		return options.Boolean
+	}
+	return o.Kind
+}
+
+func word(r *rand.Rand, choices []string) string {
+	if len(choices) > 0 {
+		return choices[r.Intn(len(choices))]
+	}
+	return "w" + strconv.Itoa(r.Intn(1000))
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/cions/go-options"
+)
+
+func newGeneratorSpec() *options.Spec {
+	return &options.Spec{
+		Name: "example",
+		Options: []*options.OptionSpec{
+			{Names: []string{"-v", "--verbose"}},
+			{Names: []string{"-f", "--file"}, Kind: options.Required, Required: true},
+			{Names: []string{"--color"}, Kind: options.Optional, Choices: []string{"always", "never"}},
+			{Names: []string{"-D"}, Kind: options.TakeTwoArgs},
+		},
+		Commands: []*options.Spec{
+			{
+				Name:    "run",
+				Options: []*options.OptionSpec{{Names: []string{"-n", "--dry-run"}}},
+				Positional: []*options.ArgSpec{
+					{Name: "COMMAND", Choices: []string{"ls", "cat"}},
+					{Name: "ARGS", Variadic: true},
+				},
+			},
+			{Name: "list"},
+		},
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	g := &Generator{Spec: newGeneratorSpec()}
+	r := rand.New(rand.NewSource(1))
+	for range 200 {
+		if args := g.Valid(r); g.Spec.Validate(args) != nil {
+			t.Errorf("Valid() = %q, rejected: %v", args, g.Spec.Validate(args))
+		}
+		if args := g.Invalid(r); g.Spec.Validate(args) == nil {
+			t.Errorf("Invalid() = %q, accepted", args)
+		}
+	}
+}
+
+func TestGeneratorQuick(t *testing.T) {
+	g := &Generator{Spec: newGeneratorSpec()}
+	parses := func(args []string) bool {
+		_, _, err := newGeneratorSpec().Parse(args)
+		return err == nil
+	}
+	if err := quick.Check(parses, &quick.Config{Values: g.Values()}); err != nil {
+		t.Error(err)
+	}
+}
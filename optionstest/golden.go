@@ -0,0 +1,91 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+var update = flag.Bool("update-golden", false, "update the golden files of optionstest")
+
+// Golden reports an error to t unless got is equal to the contents of the
+// golden file at path. If the test binary is run with -update-golden, it
+// writes got to path instead, creating the directory if needed.
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update-golden to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: mismatch at line %d (run with -update-golden to update)\n got: %q\nwant: %q",
+			path, firstDiffLine(got, want), string(got), string(want))
+	}
+}
+
+func firstDiffLine(a, b []byte) int {
+	la, lb := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	for i := range min(len(la), len(lb)) {
+		if la[i] != lb[i] {
+			return i + 1
+		}
+	}
+	return min(len(la), len(lb)) + 1
+}
+
+// GoldenDocs renders the documentation of spec and compares it with the
+// golden files in dir using Golden. The following files are compared, where
+// NAME is the command path joined with underscores (e.g. git_remote_add):
+//
+//	NAME.help.txt      the help message of each command (StandardHelp)
+//	NAME.help2man.txt  the help message of each command (Help2ManHelp)
+//	carapace.yaml      the carapace-spec of the whole program
+//	fig.json           the Fig completion spec of the whole program
+//	schema.json        the schema of the whole program
+func GoldenDocs(t testing.TB, spec *options.Spec, dir string) {
+	t.Helper()
+	goldenCommand(t, spec, dir, spec.Name)
+	goldenRender(t, filepath.Join(dir, "carapace.yaml"), spec.WriteCarapace)
+	goldenRender(t, filepath.Join(dir, "fig.json"), spec.WriteFig)
+	goldenRender(t, filepath.Join(dir, "schema.json"), spec.WriteSchema)
+}
+
+func goldenCommand(t testing.TB, spec *options.Spec, dir, name string) {
+	t.Helper()
+	goldenRender(t, filepath.Join(dir, name+".help.txt"), func(w io.Writer) error {
+		return spec.WriteHelp(w, options.StandardHelp)
+	})
+	goldenRender(t, filepath.Join(dir, name+".help2man.txt"), func(w io.Writer) error {
+		return spec.WriteHelp(w, options.Help2ManHelp)
+	})
+	for _, cmd := range spec.Commands {
+		goldenCommand(t, cmd, dir, name+"_"+cmd.Name)
+	}
+}
+
+func goldenRender(t testing.TB, path string, render func(io.Writer) error) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	Golden(t, path, buf.Bytes())
+}
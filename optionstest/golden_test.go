@@ -0,0 +1,59 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+func TestGoldenDocs(t *testing.T) {
+	spec := &options.Spec{
+		Name: "example",
+		Options: []*options.OptionSpec{
+			{Names: []string{"-v", "--verbose"}, Help: "be verbose"},
+		},
+		Commands: []*options.Spec{
+			{Name: "run", Summary: "Run a command"},
+		},
+	}
+	dir := t.TempDir()
+
+	*update = true
+	GoldenDocs(t, spec, dir)
+	*update = false
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := []string{
+		"carapace.yaml",
+		"example.help.txt",
+		"example.help2man.txt",
+		"example_run.help.txt",
+		"example_run.help2man.txt",
+		"fig.json",
+		"schema.json",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("golden files = %q, want %q", names, want)
+	}
+
+	GoldenDocs(t, spec, dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, "example_run.help.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, filepath.Join(dir, "example_run.help.txt"), data)
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+// Package optionstest provides utilities for testing programs that use
+// the options package.
+package optionstest
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/cions/go-options"
+)
+
+// Complete returns the candidates the runtime completion of spec produces for
+// the simulated command line. cursor is the byte offset of the cursor in line.
+// The first word of line is the command name. Words are split on whitespace.
+func Complete(spec *options.Spec, line string, cursor int) []string {
+	line = line[:cursor]
+	words := strings.Fields(line)
+	if line == "" || unicode.IsSpace(rune(line[len(line)-1])) {
+		words = append(words, "")
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	return spec.Complete(words[1:])
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+var testSpec = &options.Spec{
+	Name: "example",
+	Options: []*options.OptionSpec{
+		{Names: []string{"-v", "--verbose"}},
+		{Names: []string{"--color"}, Kind: options.Required, Choices: []string{"always", "never", "auto"}},
+		{Names: []string{"--secret"}, Hidden: true},
+	},
+	Commands: []*options.Spec{
+		{
+			Name:    "run",
+			Options: []*options.OptionSpec{{Names: []string{"-n", "--dry-run"}}},
+			Positional: []*options.ArgSpec{
+				{Name: "MODE", Choices: []string{"fast", "slow"}},
+			},
+		},
+		{Name: "reset"},
+		{Name: "debug", Hidden: true},
+	},
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		line     string
+		cursor   int
+		expected []string
+	}{
+		{"example ", -1, []string{"run", "reset"}},
+		{"example r", -1, []string{"run", "reset"}},
+		{"example ru", -1, []string{"run"}},
+		{"example --", -1, []string{"--verbose", "--color"}},
+		{"example --color ", -1, []string{"always", "never", "auto"}},
+		{"example --color a", -1, []string{"always", "auto"}},
+		{"example --color=n", -1, []string{"--color=never"}},
+		{"example -v run -", -1, []string{"-n", "--dry-run", "-v", "--verbose", "--color"}},
+		{"example run -n ", -1, []string{"fast", "slow"}},
+		{"example run fast ", -1, nil},
+		{"example ru --verbose", 10, []string{"run"}},
+		{"example", -1, nil},
+	}
+	for _, tt := range tests {
+		cursor := tt.cursor
+		if cursor < 0 {
+			cursor = len(tt.line)
+		}
+		actual := Complete(testSpec, tt.line, cursor)
+		if !slices.Equal(actual, tt.expected) {
+			t.Errorf("%q: expected %q, got %q", tt.line[:cursor], tt.expected, actual)
+		}
+	}
+}
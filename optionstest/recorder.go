@@ -0,0 +1,154 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+// Call is a method call recorded by a Recorder.
+type Call struct {
+	// Method is one of "Kind", "Option", "OptionN", "Arg" and "Args".
+	Method string
+
+	// Name is the option name given to Kind, Option and OptionN.
+	Name string
+
+	// Value is the value argument of Option and Arg.
+	Value string
+
+	// HasValue is the hasValue argument of Option.
+	HasValue bool
+
+	// Values is the argument of OptionN.
+	Values []string
+
+	// Index and AfterDDash are the arguments of Arg.
+	Index      int
+	AfterDDash bool
+
+	// Before and After are the arguments of Args.
+	Before, After []string
+
+	// Kind is the result of Kind.
+	Kind options.Kind
+
+	// Err is the error returned by the wrapped method.
+	Err error
+}
+
+// String formats the call like Go source, e.g. Option("-f", "x", true).
+func (c Call) String() string {
+	switch c.Method {
+	case "Kind":
+		return fmt.Sprintf("Kind(%q)", c.Name)
+	case "Option":
+		return fmt.Sprintf("Option(%q, %q, %t)", c.Name, c.Value, c.HasValue)
+	case "OptionN":
+		return fmt.Sprintf("OptionN(%q, %q)", c.Name, c.Values)
+	case "Arg":
+		return fmt.Sprintf("Arg(%d, %q, %t)", c.Index, c.Value, c.AfterDDash)
+	case "Args":
+		return fmt.Sprintf("Args(%q, %q)", c.Before, c.After)
+	default:
+		return c.Method + "()"
+	}
+}
+
+// Recorder wraps an Options and records every method call made on it by the
+// parser. Recorder implements OptionsWithOptionN, OptionsWithArg and
+// OptionsWithArgs; the calls are forwarded only if the wrapped Options
+// implements the corresponding interface.
+type Recorder struct {
+	opts  options.Options
+	Calls []Call
+}
+
+// NewRecorder returns a Recorder wrapping opts.
+func NewRecorder(opts options.Options) *Recorder {
+	return &Recorder{opts: opts}
+}
+
+// Kind implements options.Options.
+func (r *Recorder) Kind(name string) options.Kind {
+	kind := r.opts.Kind(name)
+	r.Calls = append(r.Calls, Call{Method: "Kind", Name: name, Kind: kind})
+	return kind
+}
+
+// Option implements options.Options.
+func (r *Recorder) Option(name, value string, hasValue bool) error {
+	err := r.opts.Option(name, value, hasValue)
+	r.Calls = append(r.Calls, Call{Method: "Option", Name: name, Value: value, HasValue: hasValue, Err: err})
+	return err
+}
+
+// OptionN implements options.OptionsWithOptionN. Like the parser, it panics
+// if the wrapped Options does not implement OptionsWithOptionN.
+func (r *Recorder) OptionN(name string, values []string) error {
+	nopts, ok := r.opts.(options.OptionsWithOptionN)
+	if !ok {
+		panic("Kind() returns TakeTwoArgs or TakeNArgs but OptionN method is not implemented")
+	}
+	err := nopts.OptionN(name, values)
+	r.Calls = append(r.Calls, Call{Method: "OptionN", Name: name, Values: slices.Clone(values), Err: err})
+	return err
+}
+
+// Arg implements options.OptionsWithArg.
+func (r *Recorder) Arg(index int, value string, afterDDash bool) error {
+	var err error
+	if aopts, ok := r.opts.(options.OptionsWithArg); ok {
+		err = aopts.Arg(index, value, afterDDash)
+	}
+	r.Calls = append(r.Calls, Call{Method: "Arg", Index: index, Value: value, AfterDDash: afterDDash, Err: err})
+	return err
+}
+
+// Args implements options.OptionsWithArgs.
+func (r *Recorder) Args(before, after []string) error {
+	var err error
+	if aopts, ok := r.opts.(options.OptionsWithArgs); ok {
+		err = aopts.Args(before, after)
+	}
+	r.Calls = append(r.Calls, Call{Method: "Args", Before: slices.Clone(before), After: slices.Clone(after), Err: err})
+	return err
+}
+
+// Filter returns the recorded calls of the given methods, in order.
+func (r *Recorder) Filter(methods ...string) []Call {
+	var calls []Call
+	for _, c := range r.Calls {
+		if slices.Contains(methods, c.Method) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset discards the recorded calls.
+func (r *Recorder) Reset() {
+	r.Calls = nil
+}
+
+// Expect reports an error to t unless the recorded calls of the given
+// methods, formatted by Call.String, are equal to want. If methods is empty,
+// the calls of all methods except Kind are compared.
+func (r *Recorder) Expect(t testing.TB, methods []string, want ...string) {
+	t.Helper()
+	if len(methods) == 0 {
+		methods = []string{"Option", "OptionN", "Arg", "Args"}
+	}
+	var got []string
+	for _, c := range r.Filter(methods...) {
+		got = append(got, c.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected calls:\n got: %q\nwant: %q", got, want)
+	}
+}
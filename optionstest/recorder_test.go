@@ -0,0 +1,55 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package optionstest
+
+import (
+	"testing"
+
+	"github.com/cions/go-options"
+)
+
+type testOptions struct{}
+
+func (*testOptions) Kind(name string) options.Kind {
+	switch name {
+	case "-a":
+		return options.Boolean
+	case "-r":
+		return options.Required
+	case "-s":
+		return options.TakeTwoArgs
+	default:
+		return options.Unknown
+	}
+}
+
+func (*testOptions) Option(name, value string, hasValue bool) error { return nil }
+
+func (*testOptions) OptionN(name string, values []string) error { return nil }
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(&testOptions{})
+	if _, err := options.Parse(r, []string{"-ar", "x", "y", "-s", "k", "v", "--", "z"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Expect(t, nil,
+		`Option("-a", "", false)`,
+		`Option("-r", "x", true)`,
+		`Arg(0, "y", false)`,
+		`OptionN("-s", ["k" "v"])`,
+		`Arg(1, "z", true)`,
+		`Args(["y"], ["z"])`,
+	)
+	r.Expect(t, []string{"Kind"}, `Kind("-a")`, `Kind("-r")`, `Kind("-s")`)
+	if calls := r.Filter("Kind"); calls[1].Kind != options.Required {
+		t.Errorf("Kind result = %v, want Required", calls[1].Kind)
+	}
+
+	r.Reset()
+	if _, err := options.Parse(r, []string{"-b"}); err == nil {
+		t.Errorf("expected error")
+	}
+	r.Expect(t, []string{"Kind"}, `Kind("-b")`)
+	r.Expect(t, nil)
+}
@@ -0,0 +1,357 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Parser parses command lines like the package-level functions, with the
+// behaviors selected by its fields, and reuses its internal buffers across
+// calls to reduce allocations when parsing many command lines. The zero value
+// is ready to use and parses like the package-level Parse.
+//
+// The positional arguments returned by a Parser share storage with its
+// buffers and are only valid until the next call on the same Parser.
+// A Parser must not be used concurrently.
+type Parser struct {
+	// Logger, if not nil, receives a trace of the parsing decisions at
+	// slog.LevelDebug: the results of Kind, the tokens with the values
+	// attached to options, and the outcomes of the callbacks.
+	Logger *slog.Logger
+
+	// EarlyExit stops parsing options at the first non-option argument, as
+	// in POSIX and ParsePOSIX.
+	EarlyExit bool
+
+	// NoDDash passes a -- found where an option is expected as a positional
+	// argument instead of absorbing it. Combined with EarlyExit, as in ParseS,
+	// a -- following the options is returned as the first positional
+	// argument.
+	NoDDash bool
+
+	// Prompter, if not nil, is asked for the value of a Required option
+	// given as the last argument without a value, instead of failing.
+	// See TerminalPrompter.
+	Prompter Prompter
+
+	// KeepDDash preserves the -- that terminates the options as a positional
+	// argument, e.g. for wrappers forwarding the command line to another
+	// program unchanged. It is passed to the Arg method with afterDDash
+	// false, and is the first element of the after argument of the Args
+	// method.
+	KeepDDash bool
+
+	// Metrics, if not nil, receives counts and durations of the parses.
+	Metrics Metrics
+
+	// Resolvers maps prefixes of option values, e.g. "secret://", to the
+	// Resolvers that replace the values having them before they are passed
+	// to the handlers. The references, not the resolved values, are logged
+	// and recorded in ParseResult.
+	Resolvers map[string]Resolver
+
+	// ResolveTimeout, if positive, limits the time taken by each resolution.
+	ResolveTimeout time.Duration
+
+	// StrictOptional rejects a short Optional option without a value that
+	// is followed by a non-option argument, as in -o VALUE, since the value
+	// of an Optional option must be attached (-oVALUE). A long Optional
+	// option followed by an argument, as in --name FILE, is accepted as
+	// usual, and the argument is positional.
+	StrictOptional bool
+
+	// ShortEquals strips the = from a value attached to a short Required or
+	// Optional option, so that -n=42 is accepted as -n42. A value starting
+	// with = can still be given as the next argument of a Required option.
+	ShortEquals bool
+
+	// BoolValues accepts a value for a long Boolean option, as in
+	// --name=false, which is passed to the Option method with hasValue true.
+	// It eases migration from the flag package.
+	BoolValues bool
+
+	// DigitOptions supports digit options such as -1 to -9 of gzip alongside
+	// negative numbers: an argument starting with a dash and a digit is a
+	// group of short options if Kind knows the first digit as an option, and
+	// a positional argument otherwise, e.g. -9v and -1.5 given -9 and -v.
+	DigitOptions bool
+
+	// NegativeNumbers accepts negative numbers such as -5 and -3.14 as
+	// positional arguments, unless Kind knows the dash and the first digit
+	// as an option, e.g. for calculators and seq(1). They are also accepted
+	// as the values of NoDashValue options.
+	NegativeNumbers bool
+
+	// PlusOptions accepts options starting with a plus sign, as in sh(1) and
+	// xterm(1), whose names are passed to Kind and Option verbatim. An
+	// argument such as +ls is a single option if Kind knows it as a whole,
+	// taking its value after = like a long option, and a group of short
+	// options such as +e and +x otherwise.
+	PlusOptions bool
+
+	// SingleDashLong accepts long options with a single dash, as in X11 and
+	// Java programs: an argument such as -geometry is a single option if Kind
+	// knows it as a whole, taking its value after = or as the next argument
+	// like a long option, and a group of short options otherwise.
+	SingleDashLong bool
+
+	// NoBundling disables the grouping of short options: an argument such as
+	// -abc is always a single option named -abc, taking its value after = or
+	// as the next argument like a long option, as in legacy programs and
+	// find(1). The values of short options can then only be given as the next
+	// argument.
+	NoBundling bool
+
+	// LongOnly makes every option a long option, as in the flag package:
+	// options may be given with one or two dashes, and take their values
+	// after = or as the next argument, without grouping or attached values.
+	// Boolean options accept values as with BoolValues.
+	// The names are passed to Kind and Option as -x for a single character
+	// and as --name otherwise, whichever was given. It eases migration of
+	// programs using the flag package; see FromFlagSet.
+	LongOnly bool
+
+	// Passthrough passes the unknown options through as positional
+	// arguments, in their original order and form, instead of failing, e.g.
+	// for wrappers forwarding them to another program. An unknown short
+	// option in a group is passed with the rest of the group, as -xyz for -x
+	// in -axyz. Values given as separate arguments are passed as ordinary
+	// positional arguments, since the parser cannot tell whether an unknown
+	// option takes them, and end the options in EarlyExit mode.
+	Passthrough bool
+
+	// CollectErrors continues the parse past the errors about the command
+	// line, such as unknown options and invalid values, skipping the rest of
+	// the offending argument, and fails at the end with all of them, joined
+	// by errors.Join if there are several. Errors such as ErrHelp still stop
+	// the parse immediately.
+	CollectErrors bool
+
+	// StopAtUnknown ends the options at the first unknown option, which is
+	// returned with all the following arguments, including any --, as
+	// positional arguments, like the command of exec wrappers such as env(1)
+	// and sudo(8). An unknown short option in a group is returned with the
+	// rest of the group. It takes precedence over Passthrough.
+	StopAtUnknown bool
+
+	// IgnoreCase matches long options case-insensitively, as in Windows
+	// programs: their names are lowercased before they are passed to Kind and
+	// Option, so that --Output and --OUTPUT are both --output. The short
+	// options and the values keep their case.
+	IgnoreCase bool
+
+	// Abbreviations accepts unambiguous prefixes of the long options, as in
+	// GNU getopt_long, so that --verb is --verbose unless another option
+	// starts with --verb, which is an error with CodeAmbiguousOption. It
+	// requires opts to implement OptionsWithNames; Spec does.
+	Abbreviations bool
+
+	// DefaultCommand, if not empty, is returned by ParseS as the subcommand
+	// when none is given, instead of ErrNoSubcommand, e.g. "help" or
+	// "list-units".
+	DefaultCommand string
+
+	// DDashGroups makes every -- following the first one a separator of
+	// groups of positional arguments, as in "cmd FILES -- BUILD-ARGS --
+	// RUN-ARGS", instead of a positional argument. The separators are not
+	// passed to the Arg and Args methods, and the groups are available
+	// through Groups.
+	DDashGroups bool
+
+	// Prefixes, if not nil, lists the prefixes that introduce options, such
+	// as {"--"} for long options only, {"-", "+"} for short options with both
+	// signs, or {"/"} for Windows-style options. The arguments starting with
+	// none of them are positional. "-" and "+" introduce groups of short
+	// options, "--" long options, and any other prefix options processed
+	// like long options. The names passed to Kind and Option include the
+	// prefix. A -- still terminates the options.
+	Prefixes []string
+
+	// ResponseFiles replaces every argument of the form @FILE with the
+	// arguments read from FILE, as compilers do to overcome the limits on
+	// the length of command lines. The contents are split like a POSIX shell
+	// by SplitArgs, so the arguments may be given one per line or quoted,
+	// and may refer to other response files; a file including itself is an
+	// error. The arguments following a -- are not expanded. The indices
+	// reported by Stop, ArgsIndexed and ParseResult refer to the expanded
+	// argument list.
+	ResponseFiles bool
+
+	positional []string
+	warnings   []error
+	errs       []error
+	bounds     []int
+	groups     [][]string
+	unknown    []string
+	result     *ParseResult
+	perm       *permutation
+	stop       int
+	stats      Stats
+	ctx        context.Context
+}
+
+// Parse is like the package-level Parse, but reuses the buffers of p.
+func (p *Parser) Parse(opts Options, args []string) ([]string, error) {
+	args, _, err := p.parse(opts, args, 0)
+	return args, err
+}
+
+// ParseContext is like the package-level ParseContext, but reuses the
+// buffers of p.
+func (p *Parser) ParseContext(ctx context.Context, opts Options, args []string) ([]string, error) {
+	p.ctx = ctx
+	defer func() { p.ctx = nil }()
+	return p.Parse(opts, args)
+}
+
+// ParsePOSIX is like the package-level ParsePOSIX, but reuses the buffers of p.
+func (p *Parser) ParsePOSIX(opts Options, args []string) ([]string, error) {
+	args, _, err := p.parse(opts, args, earlyExit)
+	return args, err
+}
+
+// ParseKnown is like the package-level ParseKnown, but reuses the buffers of
+// p.
+func (p *Parser) ParseKnown(opts Options, args []string) (positional, unknown []string, err error) {
+	positional, _, err = p.parse(opts, args, passthrough|knownOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	return positional, p.unknown, nil
+}
+
+// ParseStrictPOSIX is like the package-level ParseStrictPOSIX, but reuses the
+// buffers of p.
+func (p *Parser) ParseStrictPOSIX(opts Options, args []string) ([]string, error) {
+	args, _, err := p.parse(opts, args, earlyExit|strictPOSIX)
+	return args, err
+}
+
+// ParseS is like the package-level ParseS, but reuses the buffers of p.
+func (p *Parser) ParseS(opts Options, args []string) ([]string, error) {
+	args, _, err := p.parse(opts, args, earlyExit|noDDash)
+	if err == nil && len(args) == 0 {
+		if p.DefaultCommand != "" {
+			return append(args, p.DefaultCommand), nil
+		}
+		return nil, ErrNoSubcommand
+	}
+	return args, err
+}
+
+// Warnings returns the warnings reported by the handlers during the last
+// parse. See Warnf.
+func (p *Parser) Warnings() []error {
+	return p.warnings
+}
+
+// Groups returns the positional arguments of the last parse in the
+// DDashGroups mode, split into the group before the first -- and one group
+// after each --. It returns nil in the other modes. The groups share storage
+// with the positional arguments.
+func (p *Parser) Groups() [][]string {
+	return p.groups
+}
+
+// Stop returns the index in the argument list at which the last parse
+// stopped scanning for options: the first non-option argument for ParsePOSIX
+// and ParseS, the argument following the -- if any, or else the length of
+// the argument list. The arguments from Stop on are all positional, so the
+// argument list can be split there, e.g. for nested parsers.
+func (p *Parser) Stop() int {
+	return p.stop
+}
+
+// collect records err and reports true if it can be collected in the
+// CollectErrors mode.
+func (p *Parser) collect(err error) bool {
+	if !p.CollectErrors {
+		return false
+	}
+	switch Code(err) {
+	case CodeUnknownOption, CodeMissingArg, CodeUnexpectedArg, CodeInvalidOption,
+		CodeInvalidValue, CodeMissingOption, CodeRepeatedOption, CodeAmbiguousOption:
+		p.errs = append(p.errs, err)
+		return true
+	}
+	return false
+}
+
+// resume collects the error that stopped t, if possible, and resumes it at
+// the next argument. A missing argument is left to the Prompter.
+func (p *Parser) resume(t *Tokenizer) bool {
+	if t.err == nil || (p.Prompter != nil && Code(t.err) == CodeMissingArg) || !p.collect(t.err) {
+		return false
+	}
+	t.err = nil
+	t.short = 0
+	t.advance(1)
+	return t.Next() || p.resume(t)
+}
+
+// collected returns the errors collected during the parse, or nil.
+func (p *Parser) collected() error {
+	switch len(p.errs) {
+	case 0:
+		return nil
+	case 1:
+		return p.errs[0]
+	default:
+		return errors.Join(p.errs...)
+	}
+}
+
+func (p *Parser) warn(h *handlers, err error) bool {
+	if Code(err) != CodeWarning {
+		return false
+	}
+	p.warnings = append(p.warnings, err)
+	if h.wopts != nil {
+		h.wopts.Warning(err)
+	}
+	return true
+}
+
+// promptMissing asks p.Prompter for the value of the option reported by the
+// error of t to be missing its argument, and handles it as if it were given
+// on the command line. It returns the error unchanged if prompting is not
+// applicable.
+func (p *Parser) promptMissing(h *handlers, t *Tokenizer, st *optionState, args []string) error {
+	e, ok := t.err.(*Error)
+	if p.Prompter == nil || !ok || e.Code != CodeMissingArg || t.index != len(args)-1 {
+		return t.err
+	}
+	kind := h.opts.Kind(e.Option)
+	if kind.Base() != Required {
+		return t.err
+	}
+	value, err := p.Prompter.Prompt(e.Option, isSecret(h.opts, e.Option))
+	if err != nil {
+		return errorf(CodeMissingArg, e.Option, "option %s requires an argument: %w", e.Option, err)
+	}
+	t.tok = Token{Kind: OptionToken, Index: t.index, Name: e.Option, Value: value, HasValue: true}
+	t.kind = kind
+	if err := p.optionToken(h, t, st, args); err != nil {
+		return err
+	}
+	p.record(&t.tok)
+	return nil
+}
+
+// Reset clears the buffers of p so that they no longer reference the
+// arguments of the previous call, keeping the allocated capacity. The
+// results of the previous call, such as Warnings, Groups and the unknown
+// options of ParseKnown, are discarded.
+func (p *Parser) Reset() {
+	clear(p.positional[:cap(p.positional)])
+	p.positional = p.positional[:0]
+	p.warnings = nil
+	p.bounds = p.bounds[:0]
+	p.groups = nil
+	p.unknown = nil
+}
@@ -0,0 +1,591 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	var p Parser
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"a", "-a", "b", "--", "c"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"a", "b", "c"})
+
+	opts = &TestOptions{}
+	args, err = p.ParsePOSIX(opts, []string{"-a", "x", "-b"})
+	if err != nil {
+		t.Fatalf("ParsePOSIX(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "-b"})
+
+	opts = &TestOptions{}
+	if _, err := p.ParseS(opts, []string{"-a"}); err != ErrNoSubcommand {
+		t.Errorf("ParseS(): expected ErrNoSubcommand, but got %v", err)
+	}
+
+	p.Reset()
+	for i, arg := range p.positional[:cap(p.positional)] {
+		if arg != "" {
+			t.Errorf("positional[%d] = %q after Reset, want empty", i, arg)
+		}
+	}
+}
+
+func TestParserAllocs(t *testing.T) {
+	args := []string{"-a", "x", "y", "-b", "z", "--", "w"}
+	var p Parser
+	opts := &boolOptions{}
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := p.Parse(opts, args); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Parser.Parse() allocated %v times per run, want 0", allocs)
+	}
+}
+
+func BenchmarkParser(b *testing.B) {
+	args := []string{"-abc", "file1", "-d", "file2", "file3", "-ef", "--", "file4"}
+	var p Parser
+	opts := &boolOptions{}
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := p.Parse(opts, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParserStop(t *testing.T) {
+	tests := []struct {
+		parse func(*Parser, Options, []string) ([]string, error)
+		args  []string
+		stop  int
+	}{
+		{(*Parser).ParsePOSIX, []string{"-a", "-r", "x", "y", "-b"}, 3},
+		{(*Parser).ParsePOSIX, []string{"-a", "--", "-b"}, 2},
+		{(*Parser).ParsePOSIX, []string{"-a", "-b"}, 2},
+		{(*Parser).ParseS, []string{"-a", "--", "-b"}, 1},
+		{(*Parser).ParseS, []string{"-ab", "cmd", "-c"}, 1},
+		{(*Parser).Parse, []string{"x", "-a", "y"}, 3},
+		{(*Parser).Parse, []string{"x", "--", "-a", "y"}, 2},
+	}
+	var p Parser
+	for _, tt := range tests {
+		if _, err := tt.parse(&p, &TestOptions{}, tt.args); err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.args, err)
+		}
+		if stop := p.Stop(); stop != tt.stop {
+			t.Errorf("%q: Stop() = %d, want %d", tt.args, stop, tt.stop)
+		}
+	}
+}
+
+func TestParserKeepDDash(t *testing.T) {
+	p := &Parser{KeepDDash: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"x", "-a", "--", "-b", "--"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "--", "-b", "--"})
+	CompareSlice(t, "Before", opts.Before, []string{"x"})
+	CompareSlice(t, "After", opts.After, []string{"--", "-b", "--"})
+	CompareSlice(t, "ArgHistory", opts.ArgHistory, []ArgCall{
+		{0, "x", false}, {1, "--", false}, {2, "-b", true}, {3, "--", true},
+	})
+
+	args, err = p.ParseS(&TestOptions{}, []string{"-a", "--", "-b"})
+	if err != nil {
+		t.Fatalf("ParseS(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"--", "-b"})
+}
+
+func TestParserStrictOptional(t *testing.T) {
+	p := &Parser{StrictOptional: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"-ox", "--optional=y", "-o", "-a", "--optional", "--", "z"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"z"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-o", "x", true}, {"--optional", "y", true}, {"-o", "", false}, {"-a", "", false}, {"--optional", "", false},
+	})
+
+	for args, msg := range map[string]string{
+		"-o x":  "option -o takes an optional argument only in the -oVALUE form",
+		"-ao -": "option -o takes an optional argument only in the -oVALUE form",
+	} {
+		_, err := p.Parse(&TestOptions{}, strings.Fields(args))
+		var e *Error
+		if !errors.As(err, &e) || e.Code != CodeUnexpectedArg || err.Error() != msg {
+			t.Errorf("%s: expected %q, got %v", args, msg, err)
+		}
+	}
+	opts = &TestOptions{}
+	args, err = p.Parse(opts, []string{"--optional", "file.txt"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"file.txt"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"--optional", "", false}})
+
+	if _, err := new(Parser).Parse(&TestOptions{}, []string{"-o", "x"}); err != nil {
+		t.Errorf("Parse(): unexpected error without StrictOptional: %v", err)
+	}
+}
+
+func TestParserShortEquals(t *testing.T) {
+	p := &Parser{ShortEquals: true}
+	opts := &TestOptions{}
+	if _, err := p.Parse(opts, []string{"-r=42", "-ao=x", "-o=", "-r", "=y", "--required==z"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-r", "42", true}, {"-a", "", false}, {"-o", "x", true}, {"-o", "", true}, {"-r", "=y", true}, {"--required", "=z", true},
+	})
+
+	opts = &TestOptions{}
+	if _, err := new(Parser).Parse(opts, []string{"-r=42"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"-r", "=42", true}})
+}
+
+func TestParserBoolValues(t *testing.T) {
+	p := &Parser{BoolValues: true}
+	opts := &TestOptions{}
+	if _, err := p.Parse(opts, []string{"--boolean=false", "--boolean=yes", "--boolean"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"--boolean", "false", true}, {"--boolean", "yes", true}, {"--boolean", "", false},
+	})
+
+	spec := &Spec{Name: "x", Options: []*OptionSpec{{Names: []string{"-v", "--verbose"}}}}
+	if _, err := p.Parse(spec, []string{"-v", "--verbose=0"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if n := spec.Lookup("-v").Count(); n != 0 {
+		t.Errorf("-v: expected 0 after --verbose=0, got %d", n)
+	}
+	if _, err := p.Parse(spec, []string{"--verbose=maybe"}); err == nil || err.Error() != `option --verbose: invalid boolean value "maybe"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := new(Parser).Parse(&TestOptions{}, []string{"--boolean=false"}); err == nil {
+		t.Errorf("Parse(): expected error without BoolValues")
+	}
+}
+
+type gzipOptions struct {
+	TestOptions
+}
+
+func (opts *gzipOptions) Kind(name string) Kind {
+	if len(name) == 2 && '1' <= name[1] && name[1] <= '9' {
+		return Boolean
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestParserDigitOptions(t *testing.T) {
+	p := &Parser{DigitOptions: true}
+	opts := &gzipOptions{}
+	args, err := p.Parse(opts, []string{"-9a", "-0.5", "-r", "-3", "-19", "-05"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"-0.5", "-05"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-9", "", false}, {"-a", "", false}, {"-r", "-3", true}, {"-1", "", false}, {"-9", "", false},
+	})
+
+	if _, err := new(Parser).Parse(&gzipOptions{}, []string{"-0.5"}); err == nil {
+		t.Errorf("Parse(): expected error without DigitOptions")
+	}
+}
+
+type xtermOptions struct {
+	TestOptions
+}
+
+func (opts *xtermOptions) Kind(name string) Kind {
+	switch name {
+	case "+a", "+b", "+ls":
+		return Boolean
+	case "+r", "+title":
+		return Required
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestParserPlusOptions(t *testing.T) {
+	p := &Parser{PlusOptions: true}
+	opts := &xtermOptions{}
+	args, err := p.Parse(opts, []string{"+ab", "-a", "+ls", "+rx", "+title=t", "+title", "u", "+", "x", "--", "+a"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"+", "x", "+a"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"+a", "", false}, {"+b", "", false}, {"-a", "", false}, {"+ls", "", false},
+		{"+r", "x", true}, {"+title", "t", true}, {"+title", "u", true},
+	})
+
+	if _, err := p.Parse(&xtermOptions{}, []string{"+ax"}); err == nil || err.Error() != `unknown option "+x"` {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	}
+	args, err = new(Parser).Parse(&xtermOptions{}, []string{"+a"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"+a"})
+}
+
+type x11Options struct {
+	TestOptions
+}
+
+func (opts *x11Options) Kind(name string) Kind {
+	switch name {
+	case "-geometry", "-X":
+		return Required
+	case "-rv":
+		return Boolean
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func TestParserSingleDashLong(t *testing.T) {
+	p := &Parser{SingleDashLong: true}
+	opts := &x11Options{}
+	args, err := p.Parse(opts, []string{"-geometry", "80x24", "-Xmx2g", "-rv", "-ab", "-geometry=1x1", "x"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-geometry", "80x24", true}, {"-X", "mx2g", true}, {"-rv", "", false},
+		{"-a", "", false}, {"-b", "", false}, {"-geometry", "1x1", true},
+	})
+
+	if _, err := new(Parser).Parse(&x11Options{}, []string{"-geometry", "1x1"}); err == nil {
+		t.Errorf("Parse(): expected error without SingleDashLong")
+	}
+}
+
+func TestParserEarlyExit(t *testing.T) {
+	p := &Parser{EarlyExit: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"-a", "x", "-b", "--", "y"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "-b", "y"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}})
+
+	p = &Parser{EarlyExit: true, NoDDash: true}
+	args, err = p.Parse(&TestOptions{}, []string{"-a", "--", "-b"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"--", "-b"})
+}
+
+func TestParserPassthrough(t *testing.T) {
+	p := &Parser{Passthrough: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"-a", "--unknown=1", "-axyz", "--host", "h", "-r", "v", "x", "--", "-z"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"--unknown=1", "-xyz", "--host", "h", "x", "-z"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "-a"}, {Name: "-a"}, {Name: "-r", Value: "v", HasValue: true},
+	})
+
+	p = &Parser{Passthrough: true, EarlyExit: true}
+	if args, err := p.Parse(&TestOptions{}, []string{"--unknown", "-a", "x", "-b"}); err != nil {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	} else {
+		CompareSlice(t, "args", args, []string{"--unknown", "x", "-b"})
+		if p.Stop() != 2 {
+			t.Errorf("Stop() = %d, want 2", p.Stop())
+		}
+	}
+
+	if _, err := new(Parser).Parse(&TestOptions{}, []string{"--unknown"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s without Passthrough, but got %v", CodeUnknownOption, err)
+	}
+}
+
+func TestParserCollectErrors(t *testing.T) {
+	p := &Parser{CollectErrors: true}
+	opts := &TestOptions{}
+	_, err := p.Parse(opts, []string{"--unknown", "-axb", "--boolean=1", "-o", "x", "-r"})
+	var got []ErrorCode
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		got = append(got, Code(err))
+	}
+	CompareSlice(t, "errors", got, []ErrorCode{CodeUnknownOption, CodeUnknownOption, CodeUnexpectedArg, CodeMissingArg})
+	if !errors.Is(err, ErrCmdline) || Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): unexpected error %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}, {Name: "-o"}})
+
+	if _, err := p.Parse(&TestOptions{}, []string{"-a", "--unknown", "x"}); err == nil || err.Error() != `unknown option "--unknown"` {
+		t.Errorf("Parse(): expected a single error, but got %v", err)
+	}
+	if _, err := p.Parse(&TestOptions{}, []string{"--unknown", "--help"}); Code(err) != CodeHelp {
+		t.Errorf("Parse(): unexpected error %v", err)
+	}
+}
+
+func TestParserStopAtUnknown(t *testing.T) {
+	p := &Parser{StopAtUnknown: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"-a", "x", "-bxv", "-c", "--", "--unknown"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "-xv", "-c", "--", "--unknown"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{Name: "-a"}, {Name: "-b"}})
+	if p.Stop() != 2 {
+		t.Errorf("Stop() = %d, want 2", p.Stop())
+	}
+
+	args, err = p.Parse(&TestOptions{}, []string{"--", "--unknown"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"--unknown"})
+}
+
+func TestParserIgnoreCase(t *testing.T) {
+	p := &Parser{IgnoreCase: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"--Boolean", "--REQUIRED=Value", "-a", "X"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"X"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "--boolean"}, {Name: "--required", Value: "Value", HasValue: true}, {Name: "-a"},
+	})
+
+	if _, err := p.Parse(&TestOptions{}, []string{"-A"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s for a short option, but got %v", CodeUnknownOption, err)
+	}
+	if _, err := new(Parser).Parse(&TestOptions{}, []string{"--Boolean"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s without IgnoreCase, but got %v", CodeUnknownOption, err)
+	}
+}
+
+func TestParserDefaultCommand(t *testing.T) {
+	p := &Parser{DefaultCommand: "help"}
+	args, err := p.ParseS(&TestOptions{}, []string{"-a"})
+	if err != nil {
+		t.Fatalf("ParseS(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"help"})
+
+	args, err = p.ParseS(&TestOptions{}, []string{"-a", "build", "-b"})
+	if err != nil {
+		t.Fatalf("ParseS(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"build", "-b"})
+}
+
+type seqOptions struct {
+	TestOptions
+}
+
+func (opts *seqOptions) Kind(name string) Kind {
+	switch name {
+	case "-1":
+		return Boolean
+	case "--step":
+		return Required | NoDashValue
+	default:
+		return opts.TestOptions.Kind(name)
+	}
+}
+
+func TestParserNegativeNumbers(t *testing.T) {
+	p := &Parser{NegativeNumbers: true}
+	opts := &seqOptions{}
+	args, err := p.Parse(opts, []string{"-5", "-a", "-3.14", "-.5", "-1", "--step", "-2", "-r", "-7"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"-5", "-3.14", "-.5"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-a", "", false}, {"-1", "", false},
+		{"--step", "-2", true}, {"-r", "-7", true},
+	})
+
+	for _, arg := range []string{"-5x", "-inf", "-12"} {
+		if _, err := p.Parse(&seqOptions{}, []string{arg}); err == nil {
+			t.Errorf("Parse(%q): expected error", arg)
+		}
+	}
+	if _, err := new(Parser).Parse(&seqOptions{}, []string{"-5"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s without NegativeNumbers, but got %v", CodeUnknownOption, err)
+	}
+}
+
+func TestParserDDashGroups(t *testing.T) {
+	p := &Parser{DDashGroups: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"a", "-a", "b", "--", "-c", "--", "--", "d", "--"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"a", "b", "-c", "d"})
+	CompareSlice(t, "Before", opts.Before, []string{"a", "b"})
+	CompareSlice(t, "After", opts.After, []string{"-c", "d"})
+	compareGroups(t, p.Groups(), [][]string{{"a", "b"}, {"-c"}, {}, {"d"}, {}})
+
+	if _, err := p.Parse(&TestOptions{}, []string{"a"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	compareGroups(t, p.Groups(), [][]string{{"a"}})
+
+	p.Reset()
+	if groups := p.Groups(); groups != nil {
+		t.Errorf("Groups() = %q after Reset, want nil", groups)
+	}
+	args, err = p.Parse(&TestOptions{}, []string{"x", "--", "y", "--", "z"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x", "y", "z"})
+	compareGroups(t, p.Groups(), [][]string{{"x"}, {"y"}, {"z"}})
+
+	p = &Parser{DDashGroups: true, KeepDDash: true}
+	args, err = p.Parse(&TestOptions{}, []string{"a", "--", "b", "--", "c"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"a", "--", "b", "c"})
+	compareGroups(t, p.Groups(), [][]string{{"a"}, {"b"}, {"c"}})
+
+	if _, err := new(Parser).Parse(&TestOptions{}, []string{"--", "--"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+}
+
+func compareGroups(t *testing.T, actual, expected [][]string) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Errorf("Groups: expected %q, got %q", expected, actual)
+		return
+	}
+	for i := range actual {
+		CompareSlice(t, fmt.Sprintf("Groups[%d]", i), actual[i], expected[i])
+	}
+}
+
+type windowsOptions struct {
+	TestOptions
+}
+
+func (opts *windowsOptions) Kind(name string) Kind {
+	switch name {
+	case "/v", "/verbose", "+a":
+		return Boolean
+	case "/out":
+		return Required
+	default:
+		return opts.TestOptions.Kind(name)
+	}
+}
+
+func TestParserPrefixes(t *testing.T) {
+	p := &Parser{Prefixes: []string{"/"}}
+	opts := &windowsOptions{}
+	args, err := p.Parse(opts, []string{"/v", "/out=x", "-a", "/verbose", "/out", "y", "/", "--", "/v"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"-a", "/", "/v"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"/v", "", false}, {"/out", "x", true}, {"/verbose", "", false}, {"/out", "y", true},
+	})
+
+	p = &Parser{Prefixes: []string{"--"}}
+	opts = &windowsOptions{}
+	args, err = p.Parse(opts, []string{"-a", "--boolean", "-r"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"-a", "-r"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"--boolean", "", false}})
+
+	p = &Parser{Prefixes: []string{"-", "+"}}
+	opts = &windowsOptions{}
+	args, err = p.Parse(opts, []string{"-ab", "+a", "x"})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"x"})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-a", "", false}, {"-b", "", false}, {"+a", "", false},
+	})
+
+	if _, err := p.Parse(&windowsOptions{}, []string{"--boolean"}); err == nil {
+		t.Errorf("Parse(): expected error for a long option")
+	}
+	if _, err := (&Parser{Prefixes: []string{"/"}}).Parse(&windowsOptions{}, []string{"/unknown"}); Code(err) != CodeUnknownOption {
+		t.Errorf("Parse(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+}
+
+type findOptions struct {
+	TestOptions
+}
+
+func (opts *findOptions) Kind(name string) Kind {
+	switch name {
+	case "-name":
+		return Required
+	case "-abc", "-print":
+		return Boolean
+	default:
+		return opts.TestOptions.Kind(name)
+	}
+}
+
+func TestParserNoBundling(t *testing.T) {
+	p := &Parser{NoBundling: true}
+	opts := &findOptions{}
+	args, err := p.Parse(opts, []string{"-abc", "-name", "*.go", "-name=x", "-a", "-r", "v", "-print", "--boolean", "."})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"."})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{"-abc", "", false}, {"-name", "*.go", true}, {"-name", "x", true}, {"-a", "", false},
+		{"-r", "v", true}, {"-print", "", false}, {"--boolean", "", false},
+	})
+
+	for _, arg := range []string{"-ab", "-rv"} {
+		if _, err := p.Parse(&findOptions{}, []string{arg}); Code(err) != CodeUnknownOption {
+			t.Errorf("Parse(%q): expected %s, but got %v", arg, CodeUnknownOption, err)
+		}
+	}
+	if _, err := new(Parser).Parse(&findOptions{}, []string{"-abc"}); err != nil {
+		t.Errorf("Parse(): -abc must be bundled by default, but got %v", err)
+	}
+}
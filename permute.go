@@ -0,0 +1,53 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+// permutation accumulates the argument list reordered by ParsePermute.
+type permutation struct {
+	options  []string
+	operands []string
+	done     int
+	ddash    bool
+}
+
+// add records the arguments of tok, which end before args[end]. The short
+// options of a group are recorded together once the group is complete.
+func (pm *permutation) add(tok *Token, args []string, end int) {
+	switch {
+	case tok.Kind == DDashToken:
+		pm.ddash = true
+	case tok.Kind == PositionalToken && !tok.Unknown:
+		pm.operands = append(pm.operands, tok.Value)
+	default:
+		pm.options = append(pm.options, args[pm.done:end]...)
+	}
+	pm.done = end
+}
+
+// ParsePermute is like Parse, but additionally returns the argument list
+// permuted like GNU getopt does: the options with their arguments in their
+// original order, followed by the -- if any, and then the operands. Unknown
+// options passed through by Parser.Passthrough count as options.
+func ParsePermute(opts Options, args []string) (positional, permuted []string, err error) {
+	return new(Parser).ParsePermute(opts, args)
+}
+
+// ParsePermute is like the package-level ParsePermute, but reuses the
+// buffers of p.
+func (p *Parser) ParsePermute(opts Options, args []string) (positional, permuted []string, err error) {
+	pm := &permutation{}
+	p.perm = pm
+	defer func() { p.perm = nil }()
+	positional, err = p.Parse(opts, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	permuted = make([]string, 0, len(args))
+	permuted = append(permuted, pm.options...)
+	if pm.ddash {
+		permuted = append(permuted, "--")
+	}
+	permuted = append(permuted, pm.operands...)
+	return positional, permuted, nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestParsePermute(t *testing.T) {
+	opts := &TestOptions{}
+	args := []string{"x", "-ab", "y", "-r", "v", "--set", "k", "v", "-o", "--", "-c", "z"}
+	positional, permuted, err := ParsePermute(opts, args)
+	if err != nil {
+		t.Fatalf("ParsePermute(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "positional", positional, []string{"x", "y", "-c", "z"})
+	CompareSlice(t, "permuted", permuted, []string{"-ab", "-r", "v", "--set", "k", "v", "-o", "--", "x", "y", "-c", "z"})
+	CompareSlice(t, "args", args, []string{"x", "-ab", "y", "-r", "v", "--set", "k", "v", "-o", "--", "-c", "z"})
+
+	p := &Parser{Passthrough: true}
+	_, permuted, err = p.ParsePermute(&TestOptions{}, []string{"x", "-axyz", "--unknown=1", "y"})
+	if err != nil {
+		t.Fatalf("ParsePermute(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "permuted", permuted, []string{"-axyz", "--unknown=1", "x", "y"})
+
+	if _, _, err := ParsePermute(&TestOptions{}, []string{"x", "--unknown"}); Code(err) != CodeUnknownOption {
+		t.Errorf("ParsePermute(): expected %s, but got %v", CodeUnknownOption, err)
+	}
+}
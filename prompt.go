@@ -0,0 +1,75 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Prompter asks the user for the value of an option that is missing from the
+// command line.
+type Prompter interface {
+	// Prompt returns the value entered for the option name. If secret is
+	// true, the input should not be echoed.
+	Prompt(name string, secret bool) (string, error)
+}
+
+// OptionsWithSecret is an interface that adds the Secret method to Options.
+//
+// Secret reports whether the value of the option is secret, e.g. a password,
+// so that it is not echoed when prompted, and is replaced with *** in the
+// errors of its handlers and in traces.
+type OptionsWithSecret interface {
+	Options
+
+	Secret(name string) bool
+}
+
+type terminalPrompter struct {
+	in  *os.File
+	out io.Writer
+	r   *bufio.Reader
+}
+
+// TerminalPrompter returns a Prompter that prompts on the standard error and
+// reads from the standard input, or nil if the standard input is not a
+// terminal, so that prompting is disabled in scripts and pipelines. Secret
+// values are read with the echo turned off by stty(1) on Unix; on the other
+// platforms, including Windows, prompting for them fails.
+func TerminalPrompter() Prompter {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &terminalPrompter{in: os.Stdin, out: os.Stderr, r: bufio.NewReader(os.Stdin)}
+}
+
+func (p *terminalPrompter) Prompt(name string, secret bool) (string, error) {
+	fmt.Fprintf(p.out, "%s: ", name)
+	if secret {
+		restore, err := disableEcho(p.in)
+		if err != nil {
+			fmt.Fprintln(p.out)
+			return "", err
+		}
+		defer func() {
+			restore()
+			fmt.Fprintln(p.out)
+		}()
+	}
+	line, err := p.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func isSecret(opts Options, name string) bool {
+	sopts, ok := opts.(OptionsWithSecret)
+	return ok && sopts.Secret(name)
+}
@@ -0,0 +1,17 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+//go:build !unix
+
+package options
+
+import (
+	"errors"
+	"os"
+)
+
+// disableEcho is not supported on this platform, so that secret input, which
+// would be echoed, cannot be prompted for.
+func disableEcho(in *os.File) (func(), error) {
+	return nil, errors.New("options: cannot read secret input without echo on this platform")
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"context"
+	"testing"
+)
+
+type fakePrompter struct {
+	Asked []string
+	Value string
+}
+
+func (p *fakePrompter) Prompt(name string, secret bool) (string, error) {
+	if secret {
+		name += " (secret)"
+	}
+	p.Asked = append(p.Asked, name)
+	return p.Value, nil
+}
+
+func TestParserPrompt(t *testing.T) {
+	prompter := &fakePrompter{Value: "answer"}
+	p := &Parser{Prompter: prompter}
+	opts := &TestOptions{}
+	if _, err := p.Parse(opts, []string{"x", "-ar"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "asked", prompter.Asked, []string{"-r"})
+	CompareSlice(t, "options", opts.OptionHistory, []OptionCall{
+		{Name: "-a"},
+		{Name: "-r", Value: "answer", HasValue: true},
+	})
+
+	if _, err := p.Parse(&TestOptions{}, []string{"--set", "k"}); Code(err) != CodeMissingArg {
+		t.Errorf("Parse(): TakeTwoArgs must not be prompted, but got %v", err)
+	}
+	if _, err := Parse(&TestOptions{}, []string{"-r"}); Code(err) != CodeMissingArg {
+		t.Errorf("Parse(): expected %s without a Prompter, but got %v", CodeMissingArg, err)
+	}
+}
+
+func TestSpecPrompt(t *testing.T) {
+	prompter := &fakePrompter{Value: "hunter2"}
+	spec := &Spec{
+		Name:     "example",
+		Prompter: prompter,
+		Options: []*OptionSpec{
+			{Names: []string{"--user"}, Kind: Required, Required: true},
+			{Names: []string{"--password"}, Kind: Required, Required: true, Secret: true},
+		},
+	}
+	if _, _, err := spec.Parse([]string{"--user", "alice"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "asked", prompter.Asked, []string{"--password (secret)"})
+	if value, _ := spec.Options[1].Value(); value != "hunter2" {
+		t.Errorf("--password = %q, want %q", value, "hunter2")
+	}
+
+	prompter.Asked = nil
+	spec.Options[0].count, spec.Options[0].values = 0, nil
+	spec.Options[1].count, spec.Options[1].values = 0, nil
+	if _, _, err := spec.Parse([]string{"--password"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "asked", prompter.Asked, []string{"--password (secret)", "--user"})
+}
+
+func TestParserPromptChecks(t *testing.T) {
+	p := &Parser{
+		Prompter: &fakePrompter{Value: "env:X"},
+		Resolvers: map[string]Resolver{
+			"env:": ResolverFunc(func(_ context.Context, ref string) (string, error) {
+				return "resolved", nil
+			}),
+		},
+	}
+	opts := &onceOptions{}
+	if _, err := p.Parse(opts, []string{"-r"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "options", opts.OptionHistory, []OptionCall{
+		{Name: "-r", Value: "resolved", HasValue: true},
+	})
+	if _, err := p.Parse(&onceOptions{}, []string{"-O", "a", "-O"}); Code(err) != CodeRepeatedOption {
+		t.Errorf("Parse(): expected %s, but got %v", CodeRepeatedOption, err)
+	}
+
+	result := &ParseResult{}
+	p = &Parser{Prompter: &fakePrompter{Value: "answer"}, result: result}
+	if _, err := p.Parse(&TestOptions{}, []string{"-a", "-r"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if n := result.Count("-r"); n != 1 {
+		t.Errorf("Count(-r) = %d, want 1", n)
+	}
+}
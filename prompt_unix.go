@@ -0,0 +1,27 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+//go:build unix
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// disableEcho turns off the echo of the terminal in with stty(1) and returns
+// a function restoring it.
+func disableEcho(in *os.File) (func(), error) {
+	cmd := exec.Command("stty", "-echo")
+	cmd.Stdin = in
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("options: cannot disable echo: %w", err)
+	}
+	return func() {
+		cmd := exec.Command("stty", "echo")
+		cmd.Stdin = in
+		cmd.Run()
+	}, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+	"strings"
+)
+
+// redacted replaces the values of secret options in errors and traces.
+const redacted = "***"
+
+// redactedError replaces the occurrences of a secret value in the message of
+// the wrapped error.
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.secret, redacted)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError hides the secret values in the message of err.
+func redactError(err error, values ...string) error {
+	for _, value := range values {
+		if value != "" {
+			err = &redactedError{err, value}
+		}
+	}
+	return err
+}
+
+// redactArgs returns a copy of args in which the values of the secret
+// options are replaced, or args itself if there are none. Only the Kind
+// method of opts is called.
+func redactArgs(opts Options, args []string) []string {
+	var redactedArgs []string
+	set := func(i int, arg string) {
+		if redactedArgs == nil {
+			redactedArgs = slices.Clone(args)
+		}
+		redactedArgs[i] = arg
+	}
+	for t := Tokenize(opts, args); t.Next(); {
+		tok := &t.tok
+		if tok.Kind != OptionToken || !(tok.HasValue || tok.Values != nil) || !isSecret(opts, tok.Name) {
+			continue
+		}
+		arg := args[tok.Index]
+		first, following := tok.Value, 1
+		if tok.Values != nil {
+			if len(tok.Values) == 0 {
+				continue
+			}
+			first, following = tok.Values[0], len(tok.Values)
+		}
+		switch {
+		case strings.HasPrefix(tok.Name, "--") && strings.HasPrefix(arg, tok.Name+"="):
+			set(tok.Index, tok.Name+"="+redacted)
+			following--
+		case !strings.HasPrefix(tok.Name, "--") && strings.HasSuffix(arg, tok.Name[1:]+first) && first != "":
+			// The value is attached to a short option, possibly in a group.
+			set(tok.Index, arg[:len(arg)-len(first)]+redacted)
+			following--
+		}
+		for i := range following {
+			set(tok.Index+1+i, redacted)
+		}
+	}
+	if redactedArgs == nil {
+		return args
+	}
+	return redactedArgs
+}
+
+// redactValues returns a slice of the same length as values in which every
+// value is replaced.
+func redactValues(values []string) []string {
+	redactedValues := make([]string, len(values))
+	for i := range redactedValues {
+		redactedValues[i] = redacted
+	}
+	return redactedValues
+}
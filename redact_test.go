@@ -0,0 +1,141 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type secretOptions struct {
+	TestOptions
+}
+
+func (opts *secretOptions) Secret(name string) bool {
+	switch name {
+	case "-r", "--required", "-s", "--set", "--number":
+		return true
+	}
+	return false
+}
+
+type secretNOptions struct {
+	TestOptions
+}
+
+func (opts *secretNOptions) Kind(name string) Kind {
+	switch name {
+	case "--key", "-k":
+		return TakeNArgs(3)
+	case "--exec", "-e":
+		return Rest
+	case "--find", "-f":
+		return TerminatedBy(";")
+	}
+	return opts.TestOptions.Kind(name)
+}
+
+func (opts *secretNOptions) OptionN(name string, values []string) error {
+	return nil
+}
+
+func (opts *secretNOptions) Secret(name string) bool {
+	switch name {
+	case "--key", "-k", "--exec", "-e", "--find", "-f":
+		return true
+	}
+	return false
+}
+
+func TestRedactError(t *testing.T) {
+	_, err := Parse(&secretOptions{}, []string{"--number=hunter2"})
+	if err == nil || strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), redacted) {
+		t.Errorf("expected a redacted error, got %v", err)
+	}
+	if Code(err) != CodeInvalidValue {
+		t.Errorf("Code() = %s, want %s", Code(err), CodeInvalidValue)
+	}
+
+	_, err = Parse(&TestOptions{}, []string{"--number=hunter2"})
+	if err == nil || !strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("expected an unredacted error, got %v", err)
+	}
+}
+
+func TestRedactTrace(t *testing.T) {
+	var log bytes.Buffer
+	p := &Parser{Logger: slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	if _, err := p.Parse(&secretOptions{}, []string{"-r", "hunter2", "-s", "k", "hunter2", "-o", "visible"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if strings.Contains(log.String(), "hunter2") || !strings.Contains(log.String(), "visible") {
+		t.Errorf("unexpected trace:\n%s", log.String())
+	}
+
+	for _, args := range [][]string{
+		{"--key", "s1", "s2", "s3"},
+		{"--find", "s1", "s2", "s3", ";"},
+		{"--exec", "s1", "s2", "s3"},
+	} {
+		trace, err := TraceParse(&secretNOptions{}, args)
+		if err != nil {
+			t.Fatalf("TraceParse(): unexpected error: %v", err)
+		}
+		var sb strings.Builder
+		if err := trace.WriteJSON(&sb); err != nil {
+			t.Fatalf("WriteJSON(): unexpected error: %v", err)
+		}
+		if strings.Contains(sb.String(), "s1") || strings.Contains(sb.String(), "s2") || strings.Contains(sb.String(), "s3") {
+			t.Errorf("%s: the secret is in the trace:\n%s", args[0], sb.String())
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"-a", "x"}, []string{"-a", "x"}},
+		{[]string{"-r", "pw", "x"}, []string{"-r", "***", "x"}},
+		{[]string{"-abrpw", "x"}, []string{"-abr***", "x"}},
+		{[]string{"-ar", "pr", "-o", "pw"}, []string{"-ar", "***", "-o", "pw"}},
+		{[]string{"--required=pw", "--required", "pw"}, []string{"--required=***", "--required", "***"}},
+		{[]string{"-s", "k", "v", "-skey", "value"}, []string{"-s", "***", "***", "-s***", "***"}},
+		{[]string{"--", "-r", "pw"}, []string{"--", "-r", "pw"}},
+	}
+	for _, tt := range tests {
+		CompareSlice(t, strings.Join(tt.args, " "), redactArgs(&secretOptions{}, tt.args), tt.expected)
+	}
+
+	tests = []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"--key", "s1", "s2", "s3", "x"}, []string{"--key", "***", "***", "***", "x"}},
+		{[]string{"-ks1", "s2", "s3", "x"}, []string{"-k***", "***", "***", "x"}},
+		{[]string{"-f", "s1", "s2", ";", "x"}, []string{"-f", "***", "***", "***", "x"}},
+		{[]string{"-fs1", "s2", ";", "x"}, []string{"-f***", "***", "***", "x"}},
+		{[]string{"x", "--exec", "s1", "s2"}, []string{"x", "--exec", "***", "***"}},
+		{[]string{"-aes1", "s2"}, []string{"-ae***", "***"}},
+		{[]string{"--exec"}, []string{"--exec"}},
+	}
+	for _, tt := range tests {
+		CompareSlice(t, strings.Join(tt.args, " "), redactArgs(&secretNOptions{}, tt.args), tt.expected)
+	}
+
+	trace, err := TraceParse(&secretOptions{}, []string{"-a", "--number=hunter2"})
+	if err == nil {
+		t.Fatalf("TraceParse(): expected error")
+	}
+	var sb strings.Builder
+	if err := trace.WriteJSON(&sb); err != nil {
+		t.Fatalf("WriteJSON(): unexpected error: %v", err)
+	}
+	if strings.Contains(sb.String(), "hunter2") {
+		t.Errorf("the secret is in the trace:\n%s", sb.String())
+	}
+}
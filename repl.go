@@ -0,0 +1,109 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ParseLine splits line into words like a POSIX shell and parses them with
+// Parse, after clearing the values recorded by the previous parse with
+// Reset, so that interactive front-ends share the grammar of the command
+// line. Quotes, backslashes and comments are processed; expansions are not.
+func (s *Spec) ParseLine(line string) (*Spec, []string, error) {
+	args, err := SplitArgs(line)
+	if err != nil {
+		return s, nil, err
+	}
+	s.Reset()
+	return s.Parse(args)
+}
+
+// ParseLines calls ParseLine for each line read from r, skipping blank
+// lines, and calls fn with the results, so that fn can report a parse error
+// and continue. It stops at the end of r or when fn returns an error, which
+// is returned.
+func (s *Spec) ParseLines(r io.Reader, fn func(cmd *Spec, args []string, err error) error) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cmd, args, err := s.ParseLine(line)
+		if err := fn(cmd, args, err); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// SplitArgs splits s into arguments like a POSIX shell, processing single
+// and double quotes, backslashes and comments, e.g. for options stored in
+// configuration files or environment variables such as
+// MYAPP_FLAGS="-v --name 'a b'". No expansion is performed. Errors, such as
+// an unterminated quote, satisfy errors.Is(err, ErrCmdline).
+func SplitArgs(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return nil, Errorf("trailing backslash")
+			}
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+				inWord = true
+			}
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, Errorf("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+		case c == '"':
+			for i++; ; i++ {
+				if i == len(s) {
+					return nil, Errorf("unterminated double quote")
+				}
+				if s[i] == '"' {
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"  a  b\tc\n", []string{"a", "b", "c"}},
+		{`a'b c'd`, []string{"ab cd"}},
+		{`"a \"b\" \$c \d" ''`, []string{`a "b" $c \d`, ""}},
+		{`a\ b \'c\\`, []string{"a b", `'c\`}},
+		{"a # comment\nb c#d", []string{"a", "b", "c#d"}},
+		{"a\\\nb \"c\\\nd\"", []string{"ab", "cd"}},
+		{`-v --name 'a b' --path="$HOME"`, []string{"-v", "--name", "a b", "--path=$HOME"}},
+	}
+	for _, tt := range tests {
+		words, err := SplitArgs(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		CompareSlice(t, fmt.Sprintf("%q", tt.input), words, tt.expected)
+	}
+
+	for _, input := range []string{`a\`, `'a`, `"a`, `"a\"`} {
+		if _, err := SplitArgs(input); !errors.Is(err, ErrCmdline) {
+			t.Errorf("%q: expected ErrCmdline, got %v", input, err)
+		}
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	spec := newTestSpec()
+	input := "-v run 'a b'\n\n  \nrun -n c\n--bogus run\n-f\n"
+	var results []string
+	err := spec.ParseLines(strings.NewReader(input), func(cmd *Spec, args []string, err error) error {
+		if err != nil {
+			results = append(results, "error")
+			return nil
+		}
+		results = append(results, fmt.Sprintf("%s v=%d n=%d %q", cmd.Name, cmd.Lookup("-v").Count(), cmd.Lookup("-n").Count(), args))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "results", results, []string{
+		`run v=1 n=0 ["a b"]`,
+		`run v=0 n=1 ["c"]`,
+		"error",
+		"error",
+	})
+
+	errStop := errors.New("stop")
+	err = spec.ParseLines(strings.NewReader("run a\nrun b\n"), func(*Spec, []string, error) error {
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+}
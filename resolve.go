@@ -0,0 +1,60 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"context"
+	"strings"
+)
+
+// Resolver resolves references to values stored elsewhere, e.g. in a
+// secrets manager. See Parser.Resolvers.
+type Resolver interface {
+	// Resolve returns the value referenced by ref, including its prefix.
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc is an adapter to allow the use of ordinary functions as
+// Resolvers.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// resolver returns the Resolver registered for the longest prefix of value.
+func (p *Parser) resolver(value string) Resolver {
+	var r Resolver
+	var n int
+	for prefix, pr := range p.Resolvers {
+		if len(prefix) > n && strings.HasPrefix(value, prefix) {
+			r, n = pr, len(prefix)
+		}
+	}
+	return r
+}
+
+// resolve returns the value referenced by ref if it has a registered prefix,
+// and ref itself otherwise. The error mentions ref, never the value.
+func (p *Parser) resolve(name, ref string) (string, error) {
+	r := p.resolver(ref)
+	if r == nil {
+		return ref, nil
+	}
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.ResolveTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.ResolveTimeout)
+		defer cancel()
+	}
+	value, err := r.Resolve(ctx, ref)
+	if err != nil {
+		return "", errorf(CodeInvalidValue, name, "option %s: cannot resolve %s: %w", name, ref, err)
+	}
+	return value, nil
+}
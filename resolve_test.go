@@ -0,0 +1,55 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvers(t *testing.T) {
+	secrets := ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		switch ref {
+		case "secret://db/password":
+			return "hunter2", nil
+		case "secret://slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "", errors.New("not found")
+	})
+	var log bytes.Buffer
+	p := &Parser{
+		Logger:         slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Resolvers:      map[string]Resolver{"secret://": secrets},
+		ResolveTimeout: 10 * time.Millisecond,
+	}
+	opts := &TestOptions{}
+	args := []string{"--required=secret://db/password", "-s", "k", "secret://db/password", "secret://db/password"}
+	if _, err := p.Parse(opts, args); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{{"--required", "hunter2", true}})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{{"-s", []string{"k", "hunter2"}}})
+	CompareSlice(t, "args", args[3:], []string{"secret://db/password", "secret://db/password"})
+	if strings.Contains(log.String(), "hunter2") {
+		t.Errorf("the secret is logged:\n%s", log.String())
+	}
+
+	for _, ref := range []string{"secret://missing", "secret://slow"} {
+		_, err := p.Parse(&TestOptions{}, []string{"-r", ref})
+		if Code(err) != CodeInvalidValue || !strings.Contains(err.Error(), ref) {
+			t.Errorf("%s: expected %s mentioning the reference, got %v", ref, CodeInvalidValue, err)
+		}
+	}
+	_, err := p.Parse(&TestOptions{}, []string{"-r", "secret://slow"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// responseFiles expands the response files of an argument list.
+type responseFiles struct {
+	args  []string
+	files []string
+	ddash bool
+}
+
+// expandResponseFiles replaces the arguments of the form @FILE in args with
+// the arguments read from FILE, recursively. The arguments following a --
+// are not expanded.
+func expandResponseFiles(args []string) ([]string, error) {
+	if !slices.ContainsFunc(args, isResponseFile) {
+		return args, nil
+	}
+	r := &responseFiles{args: make([]string, 0, len(args))}
+	if err := r.expand(args); err != nil {
+		return nil, err
+	}
+	return r.args, nil
+}
+
+func isResponseFile(arg string) bool {
+	return len(arg) > 1 && arg[0] == '@'
+}
+
+func (r *responseFiles) expand(args []string) error {
+	for _, arg := range args {
+		if r.ddash || !isResponseFile(arg) {
+			r.ddash = r.ddash || arg == "--"
+			r.args = append(r.args, arg)
+			continue
+		}
+		name := arg[1:]
+		path, err := filepath.Abs(name)
+		if err != nil {
+			return Errorf("response file %s: %w", name, err)
+		}
+		if slices.Contains(r.files, path) {
+			return Errorf("response file %s includes itself", name)
+		}
+		data, err := os.ReadFile(name)
+		if pe, ok := err.(*fs.PathError); ok {
+			// The path is already in the message.
+			err = pe.Err
+		}
+		if err != nil {
+			return Errorf("response file %s: %w", name, err)
+		}
+		words, err := SplitArgs(strings.ReplaceAll(string(data), "\r\n", "\n"))
+		if err != nil {
+			return Errorf("response file %s: %w", name, err)
+		}
+		r.files = append(r.files, path)
+		if err := r.expand(words); err != nil {
+			return err
+		}
+		r.files = r.files[:len(r.files)-1]
+	}
+	return nil
+}
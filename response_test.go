@@ -0,0 +1,61 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	inner := write("inner.txt", "-b\r\n'y z'\r\n")
+	outer := write("outer.txt", "-r 'a b'\n@"+inner+"\n# comment\nx\n")
+
+	p := &Parser{ResponseFiles: true}
+	opts := &TestOptions{}
+	args, err := p.Parse(opts, []string{"-a", "@" + outer, "@", "--", "@" + outer})
+	if err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"y z", "x", "@", "@" + outer})
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "-a"}, {Name: "-r", Value: "a b", HasValue: true}, {Name: "-b"},
+	})
+
+	if args, err := new(Parser).Parse(&TestOptions{}, []string{"@" + outer}); err != nil {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	} else {
+		CompareSlice(t, "args", args, []string{"@" + outer})
+	}
+
+	loop := filepath.Join(dir, "loop.txt")
+	write("loop.txt", "-a @"+loop)
+	for _, arg := range []string{"@" + loop, "@" + filepath.Join(dir, "missing.txt")} {
+		if _, err := p.Parse(&TestOptions{}, []string{arg}); !errors.Is(err, ErrCmdline) {
+			t.Errorf("Parse(%q): expected ErrCmdline, but got %v", arg, err)
+		}
+	}
+	missing := filepath.Join(dir, "missing.txt")
+	if _, err := p.Parse(&TestOptions{}, []string{"@" + missing}); !errors.Is(err, fs.ErrNotExist) || err.Error() != "response file "+missing+": "+errors.Unwrap(err).Error() {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	}
+	write("twice.txt", "@"+inner+" @"+inner)
+	if args, err := p.Parse(&TestOptions{}, []string{"@" + filepath.Join(dir, "twice.txt")}); err != nil {
+		t.Errorf("Parse(): unexpected error: %v", err)
+	} else {
+		CompareSlice(t, "args", args, []string{"y z", "y z"})
+	}
+}
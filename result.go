@@ -0,0 +1,82 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+)
+
+// ParseResult is the structured outcome of ParseWithResult.
+type ParseResult struct {
+	// Positional is the list of positional arguments, as returned by Parse.
+	Positional []string
+
+	// Before and After are the positional arguments before and after the --.
+	Before, After []string
+
+	// Options is the list of options specified, in order.
+	Options []Occurrence
+
+	// Events is the list of options, positional arguments and the -- in the
+	// order they appear, for programs where the relative order of options
+	// and positional arguments matters, e.g. -i applying only to the
+	// following files.
+	Events []Token
+
+	// Warnings is the list of warnings reported by the handlers. See Warnf.
+	Warnings []error
+}
+
+// Occurrence is an option specified on the command line.
+type Occurrence struct {
+	// Name is the name of the option, including dashes.
+	Name string
+
+	// Index is the index of the argument in which the option appears.
+	Index int
+
+	// Value and HasValue are the value given to the option, as passed to
+	// the Option method.
+	Value    string
+	HasValue bool
+
+	// Values holds the values of a TakeTwoArgs option.
+	Values []string
+}
+
+// ParseWithResult is like Parse, but returns a ParseResult describing the
+// options seen, the positional arguments and the warnings. On error, the
+// result describes the command line up to the error.
+func ParseWithResult(opts Options, args []string) (*ParseResult, error) {
+	result := &ParseResult{}
+	p := &Parser{result: result}
+	positional, err := p.Parse(opts, args)
+	result.Positional = positional
+	result.Warnings = p.warnings
+	return result, err
+}
+
+// Count returns the number of occurrences of the options with any of the
+// given names, which are typically the aliases of an option.
+func (r *ParseResult) Count(names ...string) int {
+	var n int
+	for _, o := range r.Options {
+		if slices.Contains(names, o.Name) {
+			n++
+		}
+	}
+	return n
+}
+
+// Indices returns the argument indices of the occurrences of the options
+// with any of the given names.
+func (r *ParseResult) Indices(names ...string) []int {
+	var indices []int
+	for _, o := range r.Options {
+		if slices.Contains(names, o.Name) {
+			indices = append(indices, o.Index)
+		}
+	}
+	return indices
+}
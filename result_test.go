@@ -0,0 +1,69 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"fmt"
+	"testing"
+)
+
+type warnOptions struct {
+	TestOptions
+}
+
+func (opts *warnOptions) Option(name, value string, hasValue bool) error {
+	if name == "-o" {
+		return Warnf("-o is deprecated")
+	}
+	return opts.TestOptions.Option(name, value, hasValue)
+}
+
+func TestParseWithResult(t *testing.T) {
+	result, err := ParseWithResult(&warnOptions{}, []string{"-ab", "x", "--boolean", "-o", "-rv", "y", "--", "z"})
+	if err != nil {
+		t.Fatalf("ParseWithResult(): unexpected error: %v", err)
+	}
+	CompareSlice(t, "positional", result.Positional, []string{"x", "y", "z"})
+	CompareSlice(t, "before", result.Before, []string{"x", "y"})
+	CompareSlice(t, "after", result.After, []string{"z"})
+	if n := result.Count("-a", "--boolean"); n != 2 {
+		t.Errorf("Count() = %d, want 2", n)
+	}
+	CompareSlice(t, "indices", result.Indices("-a", "-b", "--boolean"), []int{0, 0, 2})
+	if o := result.Options[4]; o.Name != "-r" || o.Value != "v" || !o.HasValue || o.Index != 4 {
+		t.Errorf("unexpected occurrence: %+v", o)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Error() != "option -o: -o is deprecated" {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+	if Code(result.Warnings[0]) != CodeWarning {
+		t.Errorf("Code() = %s, want %s", Code(result.Warnings[0]), CodeWarning)
+	}
+
+	if _, err := Parse(&warnOptions{}, []string{"-o"}); err != nil {
+		t.Errorf("Parse(): warnings must not fail the parse: %v", err)
+	}
+
+	result, err = ParseWithResult(&warnOptions{}, []string{"-a", "--bogus"})
+	if err == nil {
+		t.Fatalf("ParseWithResult(): expected error")
+	}
+	if result.Count("-a") != 1 {
+		t.Errorf("result does not describe the command line up to the error")
+	}
+}
+
+func TestParseWithResultEvents(t *testing.T) {
+	result, err := ParseWithResult(&TestOptions{}, []string{"x", "-a", "y", "-r", "v", "--", "-b"})
+	if err != nil {
+		t.Fatalf("ParseWithResult(): unexpected error: %v", err)
+	}
+	var events []string
+	for _, ev := range result.Events {
+		events = append(events, fmt.Sprintf("%d %v %s%s", ev.Index, ev.Kind, ev.Name, ev.Value))
+	}
+	CompareSlice(t, "events", events, []string{
+		"0 positional x", "1 option -a", "2 positional y", "3 option -rv", "5 ddash ", "6 positional -b",
+	})
+}
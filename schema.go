@@ -0,0 +1,365 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SchemaOption is the command-line option with which plugin programs are
+// expected to write their schema to the standard output.
+const SchemaOption = "--options-schema"
+
+type schemaSpec struct {
+	Name         string          `json:"name"`
+	Aliases      []string        `json:"aliases,omitempty"`
+	Summary      string          `json:"summary,omitempty"`
+	Hidden       bool            `json:"hidden,omitempty"`
+	RequireOneOf [][]string      `json:"requireOneOf,omitempty"`
+	Options      []*schemaOption `json:"options,omitempty"`
+	Positional   []*schemaArg    `json:"positional,omitempty"`
+	Commands     []*schemaSpec   `json:"commands,omitempty"`
+}
+
+type schemaOption struct {
+	Names    []string `json:"names"`
+	Kind     string   `json:"kind"`
+	Metavar  string   `json:"metavar,omitempty"`
+	Help     string   `json:"help,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Max      int      `json:"max,omitempty"`
+	Requires []string `json:"requires,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
+	Complete string   `json:"complete,omitempty"`
+	Hidden   bool     `json:"hidden,omitempty"`
+
+	Forward    bool   `json:"forward,omitempty"`
+	Global     bool   `json:"global,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+	Secret     bool   `json:"secret,omitempty"`
+}
+
+type schemaArg struct {
+	Name     string   `json:"name"`
+	Help     string   `json:"help,omitempty"`
+	Choices  []string `json:"choices,omitempty"`
+	Complete string   `json:"complete,omitempty"`
+	Variadic bool     `json:"variadic,omitempty"`
+}
+
+var kindNames = map[Kind]string{
+	Boolean:     "boolean",
+	Required:    "required",
+	Optional:    "optional",
+	TakeTwoArgs: "take-two-args",
+	Counter:     "counter",
+	Rest:        "rest",
+	Property:    "property",
+	Toggle:      "toggle",
+}
+
+var completionNames = map[Completion]string{
+	NoCompletion:  "",
+	CompleteFiles: "files",
+	CompleteDirs:  "dirs",
+}
+
+// kindName returns the name of kind in schemas, e.g. "take-3-args" for
+// TakeNArgs(3), `terminated-by ";" "+"` for TerminatedBy(";", "+") and
+// "required|once" for Required | Once. The terminators are quoted as Go
+// strings, so that they may contain spaces and |.
+func kindName(kind Kind) string {
+	for _, m := range kindModifiers {
+		if kind&m.mod != 0 {
+			return kindName(kind&^m.mod) + "|" + strings.ToLower(m.name)
+		}
+	}
+	if kind.Base() == TakeTwoArgs && kind != TakeTwoArgs {
+		return "take-" + strconv.Itoa(kind.NArgs()) + "-args"
+	}
+	if terms := kind.terminators(); terms != nil {
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = strconv.Quote(term)
+		}
+		return "terminated-by " + strings.Join(quoted, " ")
+	}
+	return kindNames[kind]
+}
+
+// parseKindName is the inverse of kindName.
+func parseKindName(name string) (Kind, bool) {
+	if s, ok := strings.CutPrefix(name, "terminated-by "); ok {
+		var terms []string
+		for {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				break
+			}
+			term, _ := strconv.Unquote(quoted)
+			terms = append(terms, term)
+			s = strings.TrimPrefix(s[len(quoted):], " ")
+		}
+		if len(terms) == 0 {
+			return Unknown, false
+		}
+		return parseModifiers(TerminatedBy(terms...), s)
+	}
+	var mods string
+	if i := strings.IndexByte(name, '|'); i >= 0 {
+		name, mods = name[:i], name[i:]
+	}
+	if s, ok := strings.CutPrefix(name, "take-"); ok {
+		if s, ok := strings.CutSuffix(s, "-args"); ok {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 && strconv.Itoa(n) == s {
+				return parseModifiers(TakeNArgs(n), mods)
+			}
+		}
+	}
+	kind, ok := lookupName(kindNames, name)
+	if !ok {
+		return Unknown, false
+	}
+	return parseModifiers(kind, mods)
+}
+
+// parseModifiers adds to kind the modifiers named in mods, each preceded by
+// a |, as in "|once|file".
+func parseModifiers(kind Kind, mods string) (Kind, bool) {
+	if mods == "" {
+		return kind, true
+	}
+	names := strings.Split(mods, "|")
+	if names[0] != "" {
+		return Unknown, false
+	}
+	for _, name := range names[1:] {
+		var mod Kind
+		for _, m := range kindModifiers {
+			if name == strings.ToLower(m.name) {
+				mod = m.mod
+			}
+		}
+		if mod == 0 || kind&mod != 0 {
+			return Unknown, false
+		}
+		kind |= mod
+	}
+	return kind, true
+}
+
+func lookupName[K comparable](names map[K]string, name string) (K, bool) {
+	for k, v := range names {
+		if v == name {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// WriteSchema writes the spec as a JSON schema to w. Func is not exported.
+//
+// Plugin programs write their schema when invoked with SchemaOption, so that
+// the host program can merge it with ReadSchema and Merge.
+func (s *Spec) WriteSchema(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(toSchema(s))
+}
+
+func toSchema(s *Spec) *schemaSpec {
+	ss := &schemaSpec{
+		Name:         s.Name,
+		Aliases:      s.Aliases,
+		Summary:      s.Summary,
+		Hidden:       s.Hidden,
+		RequireOneOf: s.RequireOneOf,
+	}
+	for _, o := range s.Options {
+		ss.Options = append(ss.Options, &schemaOption{
+			Names:    o.Names,
+			Kind:     kindName(o.kind()),
+			Metavar:  o.Metavar,
+			Help:     o.Help,
+			Default:  o.Default,
+			Env:      o.Env,
+			Required: o.Required,
+			Max:      o.Max,
+			Requires: o.Requires,
+			Choices:  o.Choices,
+			Complete: completionNames[o.Complete],
+			Hidden:   o.Hidden,
+
+			Forward:    o.Forward,
+			Global:     o.Global,
+			Deprecated: o.Deprecated,
+			Secret:     o.Secret,
+		})
+	}
+	for _, a := range s.Positional {
+		ss.Positional = append(ss.Positional, &schemaArg{
+			Name:     a.Name,
+			Help:     a.Help,
+			Choices:  a.Choices,
+			Complete: completionNames[a.Complete],
+			Variadic: a.Variadic,
+		})
+	}
+	for _, cmd := range s.Commands {
+		ss.Commands = append(ss.Commands, toSchema(cmd))
+	}
+	return ss
+}
+
+// ReadSchema reads a spec from the JSON schema written by WriteSchema.
+func ReadSchema(r io.Reader) (*Spec, error) {
+	var ss schemaSpec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ss); err != nil {
+		return nil, fmt.Errorf("options: invalid schema: %w", err)
+	}
+	return fromSchema(&ss)
+}
+
+func fromSchema(ss *schemaSpec) (*Spec, error) {
+	s := &Spec{
+		Name:         ss.Name,
+		Aliases:      ss.Aliases,
+		Summary:      ss.Summary,
+		Hidden:       ss.Hidden,
+		RequireOneOf: ss.RequireOneOf,
+	}
+	for _, so := range ss.Options {
+		kind, ok := parseKindName(so.Kind)
+		if !ok {
+			return nil, fmt.Errorf("options: invalid schema: option %v: unknown kind %q", so.Names, so.Kind)
+		}
+		complete, ok := lookupName(completionNames, so.Complete)
+		if !ok {
+			return nil, fmt.Errorf("options: invalid schema: option %v: unknown completion %q", so.Names, so.Complete)
+		}
+		if len(so.Names) == 0 {
+			return nil, fmt.Errorf("options: invalid schema: option without names")
+		}
+		s.Options = append(s.Options, &OptionSpec{
+			Names:    so.Names,
+			Kind:     kind,
+			Metavar:  so.Metavar,
+			Help:     so.Help,
+			Default:  so.Default,
+			Env:      so.Env,
+			Required: so.Required,
+			Max:      so.Max,
+			Requires: so.Requires,
+			Choices:  so.Choices,
+			Complete: complete,
+			Hidden:   so.Hidden,
+
+			Forward:    so.Forward,
+			Global:     so.Global,
+			Deprecated: so.Deprecated,
+			Secret:     so.Secret,
+		})
+	}
+	for _, sa := range ss.Positional {
+		complete, ok := lookupName(completionNames, sa.Complete)
+		if !ok {
+			return nil, fmt.Errorf("options: invalid schema: argument %s: unknown completion %q", sa.Name, sa.Complete)
+		}
+		s.Positional = append(s.Positional, &ArgSpec{
+			Name:     sa.Name,
+			Help:     sa.Help,
+			Choices:  sa.Choices,
+			Complete: complete,
+			Variadic: sa.Variadic,
+		})
+	}
+	for _, sc := range ss.Commands {
+		cmd, err := fromSchema(sc)
+		if err != nil {
+			return nil, err
+		}
+		s.Commands = append(s.Commands, cmd)
+	}
+	return s, nil
+}
+
+// Merge adds the options and subcommands of plugin to s, so that they are
+// parsed, documented and completed by s. Options having a name already
+// defined in s and subcommands having a name already defined in s are
+// skipped. The options are shared with plugin, so plugin.ForwardArgs returns
+// the values given to them after parsing with s.
+func (s *Spec) Merge(plugin *Spec) {
+	s.init()
+	for _, o := range plugin.Options {
+		if !slices.ContainsFunc(o.Names, func(name string) bool { return s.match.lookup(name) != nil }) {
+			s.Options = append(s.Options, o)
+		}
+	}
+	for _, cmd := range plugin.Commands {
+		if s.Command(cmd.Name) == nil {
+			s.Commands = append(s.Commands, cmd)
+		}
+	}
+	s.match = nil
+}
+
+// ForwardArgs returns command-line arguments that reproduce the values
+// recorded in the options of s, for forwarding them to a plugin program.
+// The arguments are grouped by option in the order of s.Options.
+func (s *Spec) ForwardArgs() []string {
+	var args []string
+	for _, o := range s.Options {
+		name := o.Long()
+		if name == "" {
+			name = o.Names[0]
+		}
+		switch kind := o.kind(); kind.Base() {
+		case Boolean, Counter, Toggle:
+			for range o.count {
+				args = append(args, name)
+			}
+		case TakeTwoArgs:
+			n := kind.NArgs()
+			for i := 0; i+n <= len(o.values); i += n {
+				args = append(args, name)
+				args = append(args, o.values[i:i+n]...)
+			}
+		case Terminated:
+			terms := kind.terminators()
+			for i, value := range o.values {
+				if i == 0 || slices.Contains(terms, o.values[i-1]) {
+					args = append(args, name)
+				}
+				args = append(args, value)
+			}
+		case Rest:
+			if o.count > 0 {
+				args = append(args, name)
+				args = append(args, o.values...)
+			}
+		default:
+			for range o.count - len(o.values) {
+				args = append(args, name)
+			}
+			for _, value := range o.values {
+				if strings.HasPrefix(name, "--") {
+					args = append(args, name+"="+value)
+				} else {
+					args = append(args, name+value)
+				}
+			}
+		}
+	}
+	return args
+}
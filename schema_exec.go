@@ -0,0 +1,23 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+//go:build !js && !wasip1
+
+package options
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// LoadSchema runs the plugin program at path with SchemaOption and reads
+// the schema from its standard output. It is not available on js/wasm and
+// wasip1, which cannot run other programs.
+func LoadSchema(path string) (*Spec, error) {
+	out, err := exec.Command(path, SchemaOption).Output()
+	if err != nil {
+		return nil, fmt.Errorf("options: %s %s: %w", path, SchemaOption, err)
+	}
+	return ReadSchema(bytes.NewReader(out))
+}
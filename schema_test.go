@@ -0,0 +1,200 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	var sb strings.Builder
+	if err := newTestSpec().WriteSchema(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec, err := ReadSchema(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sb2 strings.Builder
+	if err := spec.WriteSchema(&sb2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != sb2.String() {
+		t.Errorf("round trip mismatch:\n%s\n%s", sb.String(), sb2.String())
+	}
+
+	input := `{"name": "x", "options": [{"names": ["--token"], "kind": "required", "forward": true, "global": true, "secret": true}]}`
+	spec, err = ReadSchema(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o := spec.Lookup("--token"); !o.Forward || !o.Global || !o.Secret {
+		t.Errorf("--token: expected Forward, Global and Secret, got %+v", o)
+	}
+	sb.Reset()
+	if err := spec.WriteSchema(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, field := range []string{`"forward": true`, `"global": true`, `"secret": true`} {
+		if !strings.Contains(sb.String(), field) {
+			t.Errorf("WriteSchema(): %s is missing:\n%s", field, sb.String())
+		}
+	}
+
+	for _, input := range []string{
+		`{"name": "x", "options": [{"names": ["-x"], "kind": "bogus"}]}`,
+		`{"name": "x", "options": [{"names": ["-x"], "kind": "take-0-args"}]}`,
+		`{"name": "x", "options": [{"names": ["-x"], "kind": "take-03-args"}]}`,
+		`{"name": "x", "options": [{"names": [], "kind": "boolean"}]}`,
+		`{"name": "x", "positional": [{"name": "X", "complete": "bogus"}]}`,
+		`{"name": "x", "unknown": true}`,
+	} {
+		if _, err := ReadSchema(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	plugin, err := ReadSchema(strings.NewReader(`{
+		"name": "plugin",
+		"options": [
+			{"names": ["-v", "--verbose"], "kind": "boolean"},
+			{"names": ["--mirror"], "kind": "required"},
+			{"names": ["--token"], "kind": "required", "secret": true},
+			{"names": ["-j", "--jobs"], "kind": "optional"},
+			{"names": ["-D"], "kind": "take-two-args"}
+		],
+		"commands": [{"name": "run"}, {"name": "sync"}]
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	host := newTestSpec()
+	host.Merge(plugin)
+	if len(host.Options) != 7 || len(host.Commands) != 2 {
+		t.Fatalf("unexpected merge result: %d options, %d commands", len(host.Options), len(host.Commands))
+	}
+	cmd, args, err := host.Parse([]string{"-v", "--mirror=a", "-j", "-j4", "-DK", "V", "--mirror", "b", "sync", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd.Name != "sync" {
+		t.Errorf("expected sync, got %v", cmd.Name)
+	}
+	CompareSlice(t, "Args", args, []string{"x"})
+	if n := host.Lookup("-v").Count(); n != 1 {
+		t.Errorf("-v: expected 1, got %v", n)
+	}
+	CompareSlice(t, "ForwardArgs", plugin.ForwardArgs(), []string{
+		"--mirror=a", "--mirror=b", "--jobs", "--jobs=4", "-D", "K", "V",
+	})
+	if !isSecret(host, "--token") {
+		t.Errorf("--token: expected a secret option after Merge")
+	}
+}
+
+func TestForwardArgsTakeNArgs(t *testing.T) {
+	spec := &Spec{
+		Name: "x",
+		Options: []*OptionSpec{
+			{Names: []string{"--rect"}, Kind: TakeNArgs(4)},
+		},
+	}
+	if _, _, err := spec.Parse([]string{"--rect", "1", "2", "3", "4", "--rect", "5", "6", "7", "8"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "ForwardArgs", spec.ForwardArgs(), []string{"--rect", "1", "2", "3", "4", "--rect", "5", "6", "7", "8"})
+
+	var sb strings.Builder
+	if err := spec.WriteSchema(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sb.String(), `"kind": "take-4-args"`) {
+		t.Errorf("unexpected schema:\n%s", sb.String())
+	}
+}
+
+func TestForwardArgsTerminatedBy(t *testing.T) {
+	spec := &Spec{
+		Name: "x",
+		Options: []*OptionSpec{
+			{Names: []string{"-x", "--exec"}, Kind: TerminatedBy(";", "+")},
+		},
+	}
+	if _, _, err := spec.Parse([]string{"--exec", "rm", "{}", ";", "-x;", "-xecho", "+"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "ForwardArgs", spec.ForwardArgs(), []string{"--exec", "rm", "{}", ";", "--exec", ";", "--exec", "echo", "+"})
+
+	var sb strings.Builder
+	if err := spec.WriteSchema(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sb.String(), `"kind": "terminated-by \";\" \"+\""`) {
+		t.Errorf("unexpected schema:\n%s", sb.String())
+	}
+	read, err := ReadSchema(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kind := read.Options[0].Kind; kind != TerminatedBy(";", "+") {
+		t.Errorf("Kind: expected TerminatedBy(\";\", \"+\"), got %v", kind)
+	}
+}
+
+func TestSchemaTerminators(t *testing.T) {
+	for _, kind := range []Kind{
+		TerminatedBy("end of args", "|"),
+		TerminatedBy(`"`, "a|once") | Once,
+		TerminatedBy(";") | File | Deprecated,
+	} {
+		spec := &Spec{Name: "x", Options: []*OptionSpec{{Names: []string{"-x"}, Kind: kind}}}
+		var sb strings.Builder
+		if err := spec.WriteSchema(&sb); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read, err := ReadSchema(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", kind, err)
+		}
+		if got := read.Options[0].Kind; got != kind {
+			t.Errorf("Kind: expected %v, got %v", kind, got)
+		}
+	}
+}
+
+func TestSchemaOnce(t *testing.T) {
+	spec := &Spec{
+		Name: "x",
+		Options: []*OptionSpec{
+			{Names: []string{"-o", "--output"}, Kind: Required | Once},
+			{Names: []string{"-q"}, Kind: Once},
+		},
+	}
+	var sb strings.Builder
+	if err := spec.WriteSchema(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	read, err := ReadSchema(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kind := read.Options[0].Kind; kind != Required|Once {
+		t.Errorf("Kind: expected Required|Once, got %v", kind)
+	}
+	if kind := read.Options[1].Kind; kind != Boolean|Once {
+		t.Errorf("Kind: expected Boolean|Once, got %v", kind)
+	}
+	if _, err := ReadSchema(strings.NewReader(`{"name": "x", "options": [{"names": ["-x"], "kind": "required|once|once"}]}`)); err == nil {
+		t.Errorf("expected error for a repeated modifier")
+	}
+
+	var e *Error
+	if _, _, err := read.Parse([]string{"-o", "a", "--output", "b"}); !errors.As(err, &e) || e.Code != CodeRepeatedOption {
+		t.Errorf("expected CodeRepeatedOption, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrFrozen is the error returned by Spec.Parse and the handler methods of
+// Spec if the spec is frozen.
+var ErrFrozen = errors.New("options: spec is frozen; call Reset before parsing again")
+
+// Snapshot is an immutable view of the values recorded in a Spec, created by
+// Spec.Freeze. It is safe for concurrent use.
+type Snapshot struct {
+	name    string
+	options map[string]*frozenOption
+}
+
+type frozenOption struct {
+	count  int
+	values []string
+	def    string
+}
+
+// Freeze returns a Snapshot of the values recorded in the options of s and
+// its parent commands, typically the command returned by Parse. The spec is
+// then frozen until Reset is called on the top-level command: Spec.Parse
+// fails with ErrFrozen, and so do the Option, OptionN and OptionCount
+// methods, so that the spec cannot be modified through the other parse
+// functions either.
+func (s *Spec) Freeze() *Snapshot {
+	root := s.root()
+	root.frozen = true
+	snap := &Snapshot{name: s.Name, options: make(map[string]*frozenOption)}
+	for c := s; c != nil; c = c.parent {
+		for _, o := range c.Options {
+			fo := &frozenOption{
+				count:  o.count,
+				values: slices.Clone(o.values),
+				def:    o.Default,
+			}
+			for _, name := range o.Names {
+				if _, ok := snap.options[name]; !ok {
+					snap.options[name] = fo
+				}
+			}
+		}
+	}
+	return snap
+}
+
+// Command returns the name of the command the snapshot was taken from.
+func (snap *Snapshot) Command() string {
+	return snap.name
+}
+
+// Count returns the number of times the option with the given name was
+// specified, or 0 if there is no such option.
+func (snap *Snapshot) Count(name string) int {
+	if fo := snap.options[name]; fo != nil {
+		return fo.count
+	}
+	return 0
+}
+
+// Value returns the last value given to the option with the given name, like
+// OptionSpec.Value.
+func (snap *Snapshot) Value(name string) (string, bool) {
+	fo := snap.options[name]
+	if fo == nil {
+		return "", false
+	}
+	if len(fo.values) == 0 {
+		return fo.def, false
+	}
+	return fo.values[len(fo.values)-1], true
+}
+
+// Values returns a copy of all values given to the option with the given name.
+func (snap *Snapshot) Values(name string) []string {
+	if fo := snap.options[name]; fo != nil {
+		return slices.Clone(fo.values)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	spec := newTestSpec()
+	cmd, _, err := spec.Parse([]string{"-v", "--file=a.txt", "run", "-n", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snap := cmd.Freeze()
+	if _, _, err := spec.Parse([]string{"run", "cmd"}); err != ErrFrozen {
+		t.Errorf("expected ErrFrozen, got %v", err)
+	}
+	if _, err := Parse(spec, []string{"-v"}); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Parse(): expected ErrFrozen, got %v", err)
+	}
+	if err := ParseValues(spec, url.Values{"file": {"b.txt"}}); !errors.Is(err, ErrFrozen) {
+		t.Errorf("ParseValues(): expected ErrFrozen, got %v", err)
+	}
+	if err := spec.FlagSet().Set("v", "true"); err != ErrFrozen {
+		t.Errorf("FlagSet(): expected ErrFrozen, got %v", err)
+	}
+	if n := spec.Lookup("-v").Count(); n != 1 {
+		t.Errorf("-v: modified while frozen, got %d occurrences", n)
+	}
+	spec.Reset()
+
+	if snap.Command() != "run" {
+		t.Errorf("Command() = %q, want run", snap.Command())
+	}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if n := snap.Count("--verbose"); n != 1 {
+				t.Errorf("--verbose: expected 1, got %v", n)
+			}
+			if n := snap.Count("-n"); n != 1 {
+				t.Errorf("-n: expected 1, got %v", n)
+			}
+			if v, ok := snap.Value("-f"); !ok || v != "a.txt" {
+				t.Errorf("-f: expected a.txt, got %v", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, ok := snap.Value("--color"); ok || v != "" {
+		t.Errorf("--color: expected no value, got %v", v)
+	}
+	if snap.Count("--bogus") != 0 || snap.Values("--bogus") != nil {
+		t.Errorf("--bogus: expected no values")
+	}
+
+	if _, _, err := spec.Parse([]string{"run", "cmd"}); err != nil {
+		t.Errorf("unexpected error after Reset: %v", err)
+	}
+}
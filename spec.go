@@ -0,0 +1,741 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"flag"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Completion describes how the values of an option or a positional argument
+// are completed.
+type Completion int
+
+const (
+	NoCompletion Completion = iota
+	CompleteFiles
+	CompleteDirs
+)
+
+// Spec is a declarative description of a command, its options, positional
+// arguments and subcommands.
+//
+// *Spec implements Options. The parsed values are recorded in the OptionSpecs.
+// Options of the parent commands are also accepted by the subcommands.
+type Spec struct {
+	// Name is the name of the command.
+	Name string
+
+	// Aliases is the list of alternative names of the command.
+	Aliases []string
+
+	// Summary is a one-line description of the command.
+	Summary string
+
+	// Version is the version of the program, used by WriteVersion.
+	Version string
+
+	// BugReport is the address for reporting bugs, used in help messages.
+	BugReport string
+
+	// Options is the list of options accepted by the command.
+	Options []*OptionSpec
+
+	// Positional describes the positional arguments.
+	Positional []*ArgSpec
+
+	// Commands is the list of subcommands.
+	Commands []*Spec
+
+	// Examples is the list of example command lines shown in the help
+	// message. See GenerateExamples.
+	Examples []string
+
+	// Hidden hides the command from help and completion.
+	Hidden bool
+
+	// RequireOneOf is the list of groups of option names, of each of which
+	// at least one option must be specified.
+	RequireOneOf [][]string
+
+	// Prompter, if not nil, is asked for the values of required options
+	// that are missing from the command line, instead of failing. Only the
+	// Prompter of the top-level command is used. See TerminalPrompter.
+	Prompter Prompter
+
+	parent    *Spec
+	match     *matcher
+	forwarded []string
+	flagSet   *flag.FlagSet
+	frozen    bool
+}
+
+// OptionSpec describes an option.
+type OptionSpec struct {
+	// Names is the list of names of the option, including dashes.
+	Names []string
+
+	// Kind defines how the option takes arguments. If Kind is Unknown, the
+	// option is Boolean.
+	Kind Kind
+
+	// Metavar is the name of the value used in help messages.
+	Metavar string
+
+	// Help is a one-line description of the option.
+	Help string
+
+	// Default is the value returned by Value if the option is not specified.
+	Default string
+
+	// Env is the list of environment variables consulted by Spec.Parse, in
+	// order, if the option is not specified on the command line.
+	Env []string
+
+	// Required indicates that the option must be specified.
+	Required bool
+
+	// Max is the maximum number of times the option may be specified on the
+	// command line. If Max is 0, the option may be repeated any number of
+	// times. Required with Max 1 requires exactly one occurrence.
+	Max int
+
+	// Requires is the list of names of the options that must also be
+	// specified if the option is, e.g. --cert for --key.
+	Requires []string
+
+	// Choices is the list of permitted values. If empty, any value is permitted.
+	Choices []string
+
+	// Complete defines how the value is completed.
+	Complete Completion
+
+	// Hidden hides the option from help and completion.
+	Hidden bool
+
+	// Forward indicates that the option is forwarded to a child process:
+	// its original arguments are recorded and returned by Spec.Forwarded.
+	Forward bool
+
+	// Global indicates that the option keeps its values across the groups
+	// of Spec.ParseGroups.
+	Global bool
+
+	// Deprecated, if not empty, marks the option Deprecated with the message
+	// of its warning, e.g. "use --bar".
+	Deprecated string
+
+	// Secret indicates that the value is secret, e.g. a password, so that it
+	// is not echoed when prompted and is redacted in errors and traces.
+	Secret bool
+
+	// Func, if not nil, is called for each occurrence of the option with the
+	// values given. values is nil if no value is given. A Boolean option
+	// given a false value, as in --name=false with Parser.BoolValues, or a
+	// Toggle option turned off is cleared, and Func is called with the value.
+	Func func(name string, values []string) error
+
+	// ResetFunc, if not nil, is called by Spec.Reset, e.g. to restore the
+	// variable written by Func to its initial value.
+	ResetFunc func()
+
+	count  int
+	values []string
+}
+
+// ArgSpec describes a positional argument.
+type ArgSpec struct {
+	// Name is the name of the argument used in help messages.
+	Name string
+
+	// Help is a one-line description of the argument.
+	Help string
+
+	// Choices is the list of values used for completion.
+	Choices []string
+
+	// Complete defines how the argument is completed.
+	Complete Completion
+
+	// Variadic indicates that the argument may be repeated.
+	Variadic bool
+}
+
+func (s *Spec) init() {
+	if s.match != nil {
+		return
+	}
+	s.match = newMatcher(s.Options)
+	for _, cmd := range s.Commands {
+		cmd.parent = s
+		cmd.init()
+	}
+}
+
+// Lookup returns the option with the given name (including dashes),
+// searching the parent commands as well. It returns nil if not found.
+func (s *Spec) Lookup(name string) *OptionSpec {
+	s.init()
+	for c := s; c != nil; c = c.parent {
+		if o := c.match.lookup(name); o != nil {
+			return o
+		}
+	}
+	return nil
+}
+
+// Command returns the subcommand with the given name or alias.
+// It returns nil if not found.
+func (s *Spec) Command(name string) *Spec {
+	for _, cmd := range s.Commands {
+		if cmd.Name == name || slices.Contains(cmd.Aliases, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Kind implements Options.
+func (s *Spec) Kind(name string) Kind {
+	if o := s.Lookup(name); o != nil {
+		if o.Deprecated != "" {
+			return o.kind() | Deprecated
+		}
+		return o.kind()
+	}
+	if f := s.lookupFlag(name); f != nil {
+		return flagKind(f)
+	}
+	return Unknown
+}
+
+// Option implements Options.
+func (s *Spec) Option(name, value string, hasValue bool) error {
+	if s.root().frozen {
+		return ErrFrozen
+	}
+	o := s.Lookup(name)
+	if o == nil {
+		if f := s.lookupFlag(name); f != nil {
+			if !hasValue {
+				value = "true"
+			}
+			return f.Value.Set(value)
+		}
+		return ErrUnknown
+	}
+	if !hasValue {
+		return o.set(name, nil)
+	}
+	if base := o.kind().Base(); base == Boolean || base == Toggle {
+		return o.setBool(name, value)
+	}
+	return o.set(name, []string{value})
+}
+
+// OptionRaw implements OptionsWithRaw. It records the arguments of options
+// marked Forward.
+func (s *Spec) OptionRaw(name, value string, hasValue bool, raw []string) error {
+	if err := s.Option(name, value, hasValue); err != nil {
+		return err
+	}
+	if o := s.Lookup(name); o != nil && o.Forward {
+		root := s.root()
+		switch {
+		case len(raw) == 0:
+		case raw[0] == name || strings.HasPrefix(raw[0], "--"):
+			root.forwarded = append(root.forwarded, raw...)
+		case len(raw) == 2:
+			// A group of short options followed by the value.
+			root.forwarded = append(root.forwarded, name, raw[1])
+		case hasValue && rawEquals(raw[0], name):
+			// A long option with a single dash, e.g. in the LongOnly and
+			// SingleDashLong modes.
+			root.forwarded = append(root.forwarded, raw[0])
+		case hasValue && len(name) > 2:
+			root.forwarded = append(root.forwarded, name+"="+value)
+		case hasValue && o.kind().Base() == Optional:
+			root.forwarded = append(root.forwarded, name+value)
+		case hasValue:
+			root.forwarded = append(root.forwarded, name, value)
+		default:
+			root.forwarded = append(root.forwarded, name)
+		}
+	}
+	return nil
+}
+
+// OptionN implements OptionsWithOptionN.
+func (s *Spec) OptionN(name string, values []string) error {
+	if s.root().frozen {
+		return ErrFrozen
+	}
+	o := s.Lookup(name)
+	if o == nil {
+		return ErrUnknown
+	}
+	if err := o.set(name, values); err != nil {
+		return err
+	}
+	if o.Forward {
+		root := s.root()
+		root.forwarded = append(root.forwarded, name)
+		root.forwarded = append(root.forwarded, values...)
+	}
+	return nil
+}
+
+// OptionCount implements OptionsWithCount. Func is called once per
+// occurrence.
+func (s *Spec) OptionCount(name string, count int) error {
+	if s.root().frozen {
+		return ErrFrozen
+	}
+	o := s.Lookup(name)
+	if o == nil {
+		return ErrUnknown
+	}
+	for range count {
+		if err := o.set(name, nil); err != nil {
+			return err
+		}
+		if o.Forward {
+			root := s.root()
+			root.forwarded = append(root.forwarded, name)
+		}
+	}
+	return nil
+}
+
+// Reset clears the values recorded in the options of s and its subcommands
+// by a previous parse, so that s can be parsed again. It also unfreezes s.
+// Flags registered to the FlagSet by other code are not reset.
+func (s *Spec) Reset() {
+	s.forwarded = nil
+	s.frozen = false
+	for _, o := range s.Options {
+		o.reset()
+	}
+	for _, cmd := range s.Commands {
+		cmd.Reset()
+	}
+}
+
+func (o *OptionSpec) reset() {
+	o.count = 0
+	o.values = nil
+	if o.ResetFunc != nil {
+		o.ResetFunc()
+	}
+}
+
+// Clone returns a deep copy of s and its subcommands without the values
+// recorded by previous parses. Func, ResetFunc and Prompter are shared with s.
+func (s *Spec) Clone() *Spec {
+	c := &Spec{
+		Name:         s.Name,
+		Aliases:      slices.Clone(s.Aliases),
+		Summary:      s.Summary,
+		Version:      s.Version,
+		BugReport:    s.BugReport,
+		Examples:     slices.Clone(s.Examples),
+		Hidden:       s.Hidden,
+		RequireOneOf: cloneGroups(s.RequireOneOf),
+		Prompter:     s.Prompter,
+		Options:      make([]*OptionSpec, len(s.Options)),
+		Positional:   make([]*ArgSpec, len(s.Positional)),
+		Commands:     make([]*Spec, len(s.Commands)),
+	}
+	for i, o := range s.Options {
+		co := *o
+		co.Names = slices.Clone(o.Names)
+		co.Env = slices.Clone(o.Env)
+		co.Choices = slices.Clone(o.Choices)
+		co.Requires = slices.Clone(o.Requires)
+		co.count = 0
+		co.values = nil
+		c.Options[i] = &co
+	}
+	for i, a := range s.Positional {
+		ca := *a
+		ca.Choices = slices.Clone(a.Choices)
+		c.Positional[i] = &ca
+	}
+	for i, cmd := range s.Commands {
+		c.Commands[i] = cmd.Clone()
+	}
+	return c
+}
+
+func cloneGroups(groups [][]string) [][]string {
+	if groups == nil {
+		return nil
+	}
+	c := make([][]string, len(groups))
+	for i, group := range groups {
+		c[i] = slices.Clone(group)
+	}
+	return c
+}
+
+// Forwarded returns the original arguments of the options marked Forward,
+// in the order they appeared on the command line, for reconstructing the
+// argument list of a child process. Options in a group of short options are
+// separated, e.g. -vx with -x marked Forward yields -x.
+func (s *Spec) Forwarded() []string {
+	return s.root().forwarded
+}
+
+// rawEquals reports whether arg is the option name, possibly written with a
+// single dash, followed by an equals sign and its value.
+func rawEquals(arg, name string) bool {
+	before, _, found := strings.Cut(arg, "=")
+	return found && (before == name || "-"+before == name)
+}
+
+// Parse parses the command-line options from the argument list, which should
+// not include the command name. If the spec has subcommands, the first
+// positional argument selects the subcommand, and the rest of the arguments
+// are parsed by it. Options not specified on the command line are then
+// looked up in the environment variables listed in their Env, and it is an
+// error if a Required option, every option of a group in RequireOneOf or an
+// option listed in the Requires of a specified option is still missing.
+// Returns the selected command and its positional arguments.
+func (s *Spec) Parse(args []string) (*Spec, []string, error) {
+	if s.root().frozen {
+		return s, nil, ErrFrozen
+	}
+	cmd, args, err := s.parse(args)
+	if err != nil {
+		return cmd, nil, err
+	}
+	for c := cmd; c != nil; c = c.parent {
+		if err := c.applyEnv(); err != nil {
+			return cmd, nil, err
+		}
+	}
+	for c := cmd; c != nil; c = c.parent {
+		for _, o := range c.Options {
+			if o.Required && o.count == 0 {
+				if err := s.promptRequired(o); err != nil {
+					return cmd, nil, err
+				}
+			}
+		}
+		if err := c.checkRequires(func(o *OptionSpec) bool { return o.count > 0 }); err != nil {
+			return cmd, nil, err
+		}
+	}
+	return cmd, args, nil
+}
+
+// checkRequires checks the RequireOneOf groups of s and the Requires of its
+// options, given whether each option is specified.
+func (s *Spec) checkRequires(specified func(o *OptionSpec) bool) error {
+	for _, group := range s.RequireOneOf {
+		if !slices.ContainsFunc(group, func(name string) bool {
+			o := s.Lookup(name)
+			return o != nil && specified(o)
+		}) {
+			return errorf(CodeMissingOption, group[0], "one of %s is required", strings.Join(group, ", "))
+		}
+	}
+	for _, o := range s.Options {
+		if !specified(o) {
+			continue
+		}
+		for _, name := range o.Requires {
+			if r := s.Lookup(name); r == nil || !specified(r) {
+				return errorf(CodeMissingOption, name, "option %s requires %s", o.Names[0], name)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseGroups parses a command line made of groups separated by the option
+// named next, e.g. --next as in curl. Each group is parsed by Parse and
+// passed to fn with the selected command and its positional arguments.
+// After each group, the options not marked Global are reset, so that they
+// apply only to the group in which they are specified.
+func (s *Spec) ParseGroups(args []string, next string, fn func(cmd *Spec, positional []string) error) error {
+	for {
+		group, rest, found := s.cutGroup(args, next)
+		cmd, positional, err := s.Parse(group)
+		if err != nil {
+			return err
+		}
+		if err := fn(cmd, positional); err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+		s.resetGroup()
+		args = rest
+	}
+}
+
+// cutGroup slices args around the first occurrence of the option next that
+// is not a value of another option nor after the --.
+func (s *Spec) cutGroup(args []string, next string) (group, rest []string, found bool) {
+	t := Tokenize(separatorOptions{s, next}, args)
+	for t.Next() {
+		if tok := &t.tok; tok.Kind == OptionToken && args[tok.Index] == next {
+			return args[:tok.Index:tok.Index], args[tok.Index+1:], true
+		}
+	}
+	return args, nil, false
+}
+
+func (s *Spec) resetGroup() {
+	for _, o := range s.Options {
+		if !o.Global {
+			o.reset()
+		}
+	}
+	for _, cmd := range s.Commands {
+		cmd.resetGroup()
+	}
+}
+
+// separatorOptions makes the group separator of ParseGroups known to the
+// Tokenizer as a Boolean option.
+type separatorOptions struct {
+	Options
+	next string
+}
+
+func (opts separatorOptions) Kind(name string) Kind {
+	if name == opts.next {
+		return Boolean
+	}
+	return opts.Options.Kind(name)
+}
+
+func (s *Spec) parse(args []string) (*Spec, []string, error) {
+	s.init()
+	p := &Parser{Prompter: s.root().Prompter}
+	if len(s.Commands) == 0 {
+		args, err := p.Parse(s, args)
+		return s, args, err
+	}
+	args, err := p.ParseS(s, args)
+	if err != nil {
+		return s, nil, err
+	}
+	cmd := s.Command(args[0])
+	if cmd == nil {
+		return s, nil, errorf(CodeUnknownCommand, "", "unknown command %q", args[0])
+	}
+	return cmd.parse(args[1:])
+}
+
+// promptRequired asks the Prompter of the top-level command for the value of
+// the missing required option o.
+func (s *Spec) promptRequired(o *OptionSpec) error {
+	name := o.Names[0]
+	prompter := s.root().Prompter
+	if prompter == nil || o.kind().Base() != Required {
+		return errorf(CodeMissingOption, name, "option %s is required", name)
+	}
+	value, err := prompter.Prompt(name, o.Secret)
+	if err != nil {
+		return errorf(CodeMissingOption, name, "option %s is required: %w", name, err)
+	}
+	if err := o.set(name, []string{value}); err != nil {
+		return wrapOptionError(name, err)
+	}
+	return nil
+}
+
+// completion returns how the values of the option are completed.
+func (o *OptionSpec) completion() Completion {
+	if o.Complete == NoCompletion && o.Kind&(File|NewFile) != 0 {
+		return CompleteFiles
+	}
+	return o.Complete
+}
+
+// Deprecation implements OptionsWithDeprecation.
+func (s *Spec) Deprecation(name string) string {
+	if o := s.Lookup(name); o != nil {
+		return o.Deprecated
+	}
+	return ""
+}
+
+// Secret implements OptionsWithSecret.
+func (s *Spec) Secret(name string) bool {
+	o := s.Lookup(name)
+	return o != nil && o.Secret
+}
+
+func (s *Spec) applyEnv() error {
+	for _, o := range s.Options {
+		if o.count > 0 {
+			continue
+		}
+		for _, env := range o.Env {
+			value, ok := os.LookupEnv(env)
+			if !ok {
+				continue
+			}
+			var err error
+			switch o.kind().Base() {
+			case Boolean, Toggle:
+				if b, perr := strconv.ParseBool(value); perr != nil {
+					err = perr
+				} else if b {
+					err = o.set(env, nil)
+				}
+			case Counter:
+				if n, perr := strconv.Atoi(value); perr != nil {
+					err = perr
+				} else {
+					for i := 0; i < n && err == nil; i++ {
+						err = o.set(env, nil)
+					}
+				}
+			default:
+				err = o.set(env, []string{value})
+			}
+			if err != nil {
+				return errorf(CodeInvalidValue, o.Names[0], "environment variable %s: %w", env, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (o *OptionSpec) kind() Kind {
+	if o.Kind.Base() == Unknown {
+		return o.Kind | Boolean
+	}
+	return o.Kind
+}
+
+// flag reports whether the option takes no value.
+func (o *OptionSpec) flag() bool {
+	base := o.kind().Base()
+	return base == Boolean || base == Counter || base == Toggle
+}
+
+func (o *OptionSpec) check(values []string) error {
+	if len(o.Choices) > 0 {
+		for _, value := range values {
+			if !slices.Contains(o.Choices, value) {
+				return Errorf("possible values are %s", quoteList(o.Choices))
+			}
+		}
+	}
+	return nil
+}
+
+func (o *OptionSpec) set(name string, values []string) error {
+	checked := values
+	if o.kind().Base() == Terminated && len(values) > 0 {
+		checked = values[:len(values)-1]
+	}
+	if err := o.check(checked); err != nil {
+		return err
+	}
+	if err := o.checkMax(name, o.count); err != nil {
+		return err
+	}
+	o.count++
+	o.values = append(o.values, values...)
+	if o.Func != nil {
+		return o.Func(name, values)
+	}
+	return nil
+}
+
+// checkMax fails if the option, already specified count times, may not be
+// specified again.
+func (o *OptionSpec) checkMax(name string, count int) error {
+	limit := o.Max
+	if o.kind()&Once != 0 {
+		limit = 1
+	}
+	if limit > 0 && count >= limit {
+		if limit == 1 {
+			return errorf(CodeRepeatedOption, name, "may not be repeated")
+		}
+		return errorf(CodeRepeatedOption, name, "may be specified at most %d times", limit)
+	}
+	return nil
+}
+
+// setBool sets the Boolean option given a value as in --name=false.
+func (o *OptionSpec) setBool(name, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return Errorf("invalid boolean value %q", value)
+	}
+	if b {
+		return o.set(name, nil)
+	}
+	o.count = 0
+	if o.Func != nil {
+		return o.Func(name, []string{value})
+	}
+	return nil
+}
+
+// Count returns the number of times the option was specified.
+func (o *OptionSpec) Count() int {
+	return o.count
+}
+
+// Value returns the last value given to the option. If no value was given,
+// it returns Default and false.
+func (o *OptionSpec) Value() (string, bool) {
+	if len(o.values) == 0 {
+		return o.Default, false
+	}
+	return o.values[len(o.values)-1], true
+}
+
+// Values returns all values given to the option.
+func (o *OptionSpec) Values() []string {
+	return o.values
+}
+
+// Short returns the first short name (e.g. -v) of the option, or "" if none.
+func (o *OptionSpec) Short() string {
+	for _, name := range o.Names {
+		if !strings.HasPrefix(name, "--") {
+			return name
+		}
+	}
+	return ""
+}
+
+// Long returns the first long name (e.g. --verbose) of the option, or "" if none.
+func (o *OptionSpec) Long() string {
+	for _, name := range o.Names {
+		if strings.HasPrefix(name, "--") {
+			return name
+		}
+	}
+	return ""
+}
+
+func quoteList(values []string) string {
+	var sb strings.Builder
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("'")
+		sb.WriteString(value)
+		sb.WriteString("'")
+	}
+	return sb.String()
+}
@@ -0,0 +1,375 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func newTestSpec() *Spec {
+	return &Spec{
+		Name:    "example",
+		Summary: "An example command",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}, Help: "verbose output"},
+			{Names: []string{"-f", "--file"}, Kind: Required, Metavar: "FILE", Help: "input file", Complete: CompleteFiles},
+			{Names: []string{"--color"}, Kind: Optional, Metavar: "WHEN", Help: "colorize output", Choices: []string{"always", "never", "auto"}},
+		},
+		Commands: []*Spec{
+			{
+				Name:    "run",
+				Aliases: []string{"r"},
+				Summary: "Run a command",
+				Options: []*OptionSpec{
+					{Names: []string{"-n", "--dry-run"}, Help: "do not run"},
+				},
+				Positional: []*ArgSpec{
+					{Name: "COMMAND", Help: "command to run"},
+					{Name: "ARGS", Help: "arguments", Complete: CompleteFiles, Variadic: true},
+				},
+			},
+		},
+	}
+}
+
+func TestSpec(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		spec := newTestSpec()
+		cmd, args, err := spec.Parse([]string{"-v", "--file=a.txt", "r", "-vn", "--color", "cat", "x"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cmd.Name != "run" {
+			t.Errorf("expected run, got %v", cmd.Name)
+		}
+		CompareSlice(t, "Args", args, []string{"cat", "x"})
+		if n := spec.Lookup("-v").Count(); n != 2 {
+			t.Errorf("-v: expected 2, got %v", n)
+		}
+		if v, ok := spec.Lookup("--file").Value(); !ok || v != "a.txt" {
+			t.Errorf("--file: expected a.txt, got %v", v)
+		}
+		if n := cmd.Lookup("--dry-run").Count(); n != 1 {
+			t.Errorf("--dry-run: expected 1, got %v", n)
+		}
+		if n := cmd.Lookup("--color").Count(); n != 1 {
+			t.Errorf("--color: expected 1, got %v", n)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := newTestSpec().Parse([]string{"--color=sometimes", "run"})
+		if !errors.Is(err, ErrCmdline) {
+			t.Errorf("expected ErrCmdline, got %#v", err)
+		}
+
+		_, _, err = newTestSpec().Parse([]string{"stop"})
+		if !errors.Is(err, ErrCmdline) {
+			t.Errorf("expected ErrCmdline, got %#v", err)
+		}
+
+		_, _, err = newTestSpec().Parse([]string{"-v"})
+		if !errors.Is(err, ErrNoSubcommand) {
+			t.Errorf("expected ErrNoSubcommand, got %#v", err)
+		}
+
+		_, _, err = newTestSpec().Parse([]string{"--dry-run", "run"})
+		if !errors.Is(err, ErrCmdline) {
+			t.Errorf("expected ErrCmdline, got %#v", err)
+		}
+	})
+}
+
+func TestSpecEnv(t *testing.T) {
+	t.Setenv("EXAMPLE_FILE", "env.txt")
+	t.Setenv("EXAMPLE_VERBOSE", "1")
+	t.Setenv("EXAMPLE_DRY_RUN", "false")
+
+	spec := newTestSpec()
+	spec.Lookup("--file").Env = []string{"EXAMPLE_UNSET", "EXAMPLE_FILE"}
+	spec.Lookup("--verbose").Env = []string{"EXAMPLE_VERBOSE"}
+	spec.Commands[0].Options[0].Env = []string{"EXAMPLE_DRY_RUN"}
+	cmd, _, err := spec.Parse([]string{"run", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := spec.Lookup("--file").Value(); !ok || v != "env.txt" {
+		t.Errorf("--file: expected env.txt, got %v", v)
+	}
+	if n := spec.Lookup("--verbose").Count(); n != 1 {
+		t.Errorf("--verbose: expected 1, got %v", n)
+	}
+	if n := cmd.Lookup("--dry-run").Count(); n != 0 {
+		t.Errorf("--dry-run: expected 0, got %v", n)
+	}
+
+	spec = newTestSpec()
+	spec.Lookup("--file").Env = []string{"EXAMPLE_FILE"}
+	if _, _, err := spec.Parse([]string{"run", "--file=arg.txt", "cmd"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := spec.Lookup("--file").Value(); v != "arg.txt" {
+		t.Errorf("--file: expected arg.txt, got %v", v)
+	}
+
+	t.Setenv("EXAMPLE_COLOR", "sometimes")
+	spec = newTestSpec()
+	spec.Lookup("--color").Env = []string{"EXAMPLE_COLOR"}
+	if _, _, err := spec.Parse([]string{"run", "cmd"}); !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+}
+
+func TestSpecRequired(t *testing.T) {
+	spec := newTestSpec()
+	spec.Lookup("--file").Required = true
+	if _, _, err := spec.Parse([]string{"run", "cmd"}); !errors.Is(err, ErrCmdline) {
+		t.Errorf("expected ErrCmdline, got %#v", err)
+	}
+
+	spec = newTestSpec()
+	spec.Lookup("--file").Required = true
+	if _, _, err := spec.Parse([]string{"run", "-f", "a.txt", "cmd"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSpecForwarded(t *testing.T) {
+	spec := &Spec{
+		Name: "wrapper",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}},
+			{Names: []string{"-x", "--extra"}, Forward: true},
+			{Names: []string{"-I", "--include"}, Kind: Required, Forward: true},
+			{Names: []string{"-D", "--define"}, Kind: TakeTwoArgs, Forward: true},
+		},
+	}
+	_, args, err := spec.Parse([]string{"-vx", "--include=a b", "-I", "c", "-vxI", "d", "-vId", "-D", "k", "v", "file", "--extra"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "args", args, []string{"file"})
+	CompareSlice(t, "forwarded", spec.Forwarded(), []string{
+		"-x", "--include=a b", "-I", "c", "-x", "-I", "d", "-I", "d", "-D", "k", "v", "--extra",
+	})
+
+	spec.Reset()
+	p := &Parser{LongOnly: true}
+	if _, err := p.Parse(spec, []string{"-include=a", "-include", "b", "--include=c", "-I=d"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "forwarded", spec.Forwarded(), []string{"-include=a", "--include", "b", "--include=c", "-I=d"})
+
+	spec = &Spec{
+		Name:    "xwrapper",
+		Options: []*OptionSpec{{Names: []string{"-geometry"}, Kind: Required, Forward: true}},
+	}
+	p = &Parser{SingleDashLong: true}
+	if _, err := p.Parse(spec, []string{"-geometry=80x24", "-geometry", "10x10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "forwarded", spec.Forwarded(), []string{"-geometry=80x24", "-geometry", "10x10"})
+
+	spec = &Spec{
+		Name:    "ccwrapper",
+		Options: []*OptionSpec{{Names: []string{"-v"}}, {Names: []string{"-O"}, Kind: Optional, Forward: true}},
+	}
+	if _, err := Parse(spec, []string{"-vOfast", "-O"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "forwarded", spec.Forwarded(), []string{"-Ofast", "-O"})
+}
+
+func TestSpecReset(t *testing.T) {
+	spec := newTestSpec()
+	spec.Lookup("--file").Forward = true
+	if _, _, err := spec.Parse([]string{"-v", "-f", "a.txt", "run", "-n", "cmd"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec.Reset()
+	if n := spec.Lookup("-v").Count(); n != 0 {
+		t.Errorf("-v: expected 0, got %v", n)
+	}
+	if v, ok := spec.Lookup("--file").Value(); ok {
+		t.Errorf("--file: expected no value, got %v", v)
+	}
+	if n := spec.Commands[0].Lookup("-n").Count(); n != 0 {
+		t.Errorf("-n: expected 0, got %v", n)
+	}
+	if len(spec.Forwarded()) != 0 {
+		t.Errorf("forwarded: expected [], got %v", spec.Forwarded())
+	}
+
+	cmd, _, err := spec.Parse([]string{"run", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := cmd.Lookup("-v").Count(); n != 0 {
+		t.Errorf("-v: expected 0, got %v", n)
+	}
+}
+
+func TestSpecClone(t *testing.T) {
+	spec := newTestSpec()
+	if _, _, err := spec.Parse([]string{"-v", "run", "cmd"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clone := spec.Clone()
+	if n := clone.Lookup("-v").Count(); n != 0 {
+		t.Errorf("-v: expected 0, got %v", n)
+	}
+	cmd, _, err := clone.Parse([]string{"r", "-vvn", "cmd"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd == spec.Commands[0] || cmd.Name != "run" {
+		t.Errorf("expected the cloned run, got %p", cmd)
+	}
+	if n := clone.Lookup("-v").Count(); n != 2 {
+		t.Errorf("clone -v: expected 2, got %v", n)
+	}
+	if n := spec.Lookup("-v").Count(); n != 1 {
+		t.Errorf("original -v: expected 1, got %v", n)
+	}
+	if n := spec.Commands[0].Lookup("-n").Count(); n != 0 {
+		t.Errorf("original -n: expected 0, got %v", n)
+	}
+	clone.Options[0].Names[0] = "-V"
+	if spec.Options[0].Names[0] != "-v" {
+		t.Errorf("Names is shared with the clone")
+	}
+}
+
+func TestSpecLimits(t *testing.T) {
+	newSpec := func() *Spec {
+		return &Spec{
+			Name: "limits",
+			Options: []*OptionSpec{
+				{Names: []string{"-o", "--output"}, Kind: Required, Required: true, Max: 1},
+				{Names: []string{"-v"}, Max: 2},
+				{Names: []string{"-a"}},
+				{Names: []string{"-b"}},
+			},
+			RequireOneOf: [][]string{{"-a", "-b"}},
+		}
+	}
+
+	if _, _, err := newSpec().Parse([]string{"-vv", "-o", "x", "-b"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		args   []string
+		code   ErrorCode
+		option string
+	}{
+		{[]string{"-a", "-o", "x", "--output=y"}, CodeRepeatedOption, "--output"},
+		{[]string{"-a", "-o", "x", "-vvv"}, CodeRepeatedOption, "-v"},
+		{[]string{"-a"}, CodeMissingOption, "-o"},
+		{[]string{"-o", "x"}, CodeMissingOption, "-a"},
+	}
+	for _, tt := range tests {
+		_, _, err := newSpec().Parse(tt.args)
+		var e *Error
+		if !errors.As(err, &e) || e.Code != tt.code || e.Option != tt.option {
+			t.Errorf("%q: expected %v for %s, got %#v", tt.args, tt.code, tt.option, err)
+		}
+	}
+}
+
+func TestSpecParseGroups(t *testing.T) {
+	spec := &Spec{
+		Name: "fetch",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}, Global: true},
+			{Names: []string{"-o", "--output"}, Kind: Required},
+			{Names: []string{"-X"}, Kind: Required},
+		},
+	}
+	var groups []string
+	err := spec.ParseGroups([]string{"-v", "-o", "--next", "a", "--next", "-X", "POST", "b", "--next", "--", "--next"}, "--next", func(cmd *Spec, positional []string) error {
+		output, _ := cmd.Lookup("-o").Value()
+		method, _ := cmd.Lookup("-X").Value()
+		groups = append(groups, fmt.Sprintf("v=%d o=%s X=%s %q", cmd.Lookup("-v").Count(), output, method, positional))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "groups", groups, []string{
+		`v=1 o=--next X= ["a"]`,
+		`v=1 o= X=POST ["b"]`,
+		`v=1 o= X= ["--next"]`,
+	})
+
+	errStop := errors.New("stop")
+	var n int
+	err = spec.ParseGroups([]string{"a", "--next", "b"}, "--next", func(*Spec, []string) error {
+		n++
+		return errStop
+	})
+	if err != errStop || n != 1 {
+		t.Errorf("expected the error of fn after 1 call, got %v after %d calls", err, n)
+	}
+}
+
+func TestSpecCounter(t *testing.T) {
+	var calls int
+	spec := &Spec{
+		Name: "counter",
+		Options: []*OptionSpec{
+			{Names: []string{"-v", "--verbose"}, Kind: Counter, Max: 4, Func: func(string, []string) error {
+				calls++
+				return nil
+			}},
+		},
+	}
+	if _, _, err := spec.Parse([]string{"-vv", "x", "--verbose"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := spec.Lookup("-v").Count(); n != 3 || calls != 3 {
+		t.Errorf("-v: expected 3 occurrences, got %d (%d calls)", n, calls)
+	}
+	CompareSlice(t, "ForwardArgs", spec.ForwardArgs(), []string{"--verbose", "--verbose", "--verbose"})
+
+	spec.Reset()
+	var e *Error
+	if _, _, err := spec.Parse([]string{"-vvvvv"}); !errors.As(err, &e) || e.Code != CodeRepeatedOption {
+		t.Errorf("expected CodeRepeatedOption, got %#v", err)
+	}
+
+	t.Setenv("COUNTER_VERBOSE", "2")
+	spec.Reset()
+	spec.Options[0].Env = []string{"COUNTER_VERBOSE"}
+	if _, _, err := spec.Parse(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := spec.Lookup("-v").Count(); n != 2 {
+		t.Errorf("-v: expected 2 from the environment, got %d", n)
+	}
+}
+
+func TestSpecRequires(t *testing.T) {
+	newSpec := func() *Spec {
+		return &Spec{
+			Name: "tls",
+			Options: []*OptionSpec{
+				{Names: []string{"--key"}, Kind: Required, Requires: []string{"--cert"}},
+				{Names: []string{"-c", "--cert"}, Kind: Required},
+			},
+		}
+	}
+	for _, args := range [][]string{nil, {"--cert", "c"}, {"--key", "k", "-c", "c"}} {
+		if _, _, err := newSpec().Parse(args); err != nil {
+			t.Errorf("%q: unexpected error: %v", args, err)
+		}
+	}
+	_, _, err := newSpec().Parse([]string{"--key", "k"})
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeMissingOption || e.Option != "--cert" || err.Error() != "option --key requires --cert" {
+		t.Errorf("expected CodeMissingOption for --cert, got %v", err)
+	}
+}
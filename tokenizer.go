@@ -0,0 +1,537 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	// PositionalToken is a positional argument.
+	PositionalToken TokenKind = iota
+
+	// OptionToken is an option, with its values if any.
+	OptionToken
+
+	// DDashToken is the -- that terminates the options.
+	DDashToken
+)
+
+var tokenKindStrings = [...]string{
+	PositionalToken: "positional",
+	OptionToken:     "option",
+	DDashToken:      "ddash",
+}
+
+// String returns a lowercase name of the TokenKind.
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenKindStrings) {
+		return tokenKindStrings[k]
+	}
+	return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Token is a unit of the command line produced by a Tokenizer.
+type Token struct {
+	Kind TokenKind
+
+	// Index is the index of the argument in which the token starts.
+	Index int
+
+	// Name is the name of the option, including dashes.
+	Name string
+
+	// Value is the value of the option, or the positional argument.
+	Value string
+
+	// HasValue reports whether the option was given a value.
+	HasValue bool
+
+	// Values holds the values of a TakeTwoArgs, TakeNArgs or Rest option.
+	// It is non-nil for them, even if a Rest option has no values.
+	Values []string
+
+	// AfterDDash reports whether the positional argument follows the --.
+	AfterDDash bool
+
+	// Unknown reports whether the positional argument is an unknown option
+	// passed through in the Passthrough mode of Parser.
+	Unknown bool
+}
+
+// Tokenizer splits a command line into tokens, consulting the Kind method of
+// opts for how options take arguments. The Option methods are not called.
+//
+// Tokens are returned by value and refer to the argument list, so iterating
+// over a command line makes no allocation per token. The only exception is
+// a short TakeTwoArgs, TakeNArgs or Rest option whose first value is
+// attached (e.g. -xVALUE VALUE), for which Values is allocated.
+type Tokenizer struct {
+	opts   Options
+	args   []string
+	flags  int
+	index  int
+	short  int
+	kind   Kind
+	ddash  bool
+	exited bool
+	tok    Token
+	err    error
+	names  []string
+
+	prefixes []string
+}
+
+// Tokenize returns a Tokenizer for the argument list, which should not
+// include the command name. Interleaving of options and non-options is
+// allowed, as with Parse.
+func Tokenize(opts Options, args []string) *Tokenizer {
+	return &Tokenizer{opts: opts, args: args}
+}
+
+// Next advances the tokenizer to the next token, which will then be
+// available through the Token method. It returns false when the tokenizer
+// reaches the end of the argument list or an error.
+func (t *Tokenizer) Next() bool {
+	if t.err != nil {
+		return false
+	}
+	if t.short > 0 {
+		return t.nextShort()
+	}
+	if t.index == len(t.args) {
+		return false
+	}
+	arg := t.args[t.index]
+	t.tok = Token{Index: t.index}
+	switch {
+	case t.ddash:
+		t.tok.Value = arg
+		t.tok.AfterDDash = true
+		t.advance(1)
+	case arg == "--" && t.flags&noDDash == 0:
+		t.tok.Kind = DDashToken
+		t.ddash = true
+		t.advance(1)
+	case t.exited, arg == "-", arg == "--", !t.option(arg), t.operand(arg):
+		t.tok.Value = arg
+		t.advance(1)
+		if t.flags&earlyExit != 0 {
+			t.exited = true
+		}
+	case t.prefixes != nil:
+		return t.nextPrefixed(arg)
+	case t.flags&strictPOSIX != 0 && strings.HasPrefix(arg, "--"):
+		name, _, _ := strings.Cut(arg, "=")
+		return t.fail(errorf(CodeInvalidOption, name, "invalid option %q; long options are not permitted", name))
+	case strings.HasPrefix(arg, "--"):
+		return t.nextLong()
+	case t.unbundled(arg), arg[0] == '+' && t.whole(arg), arg[0] == '-' && t.flags&singleDashLong != 0 && t.whole(arg):
+		return t.nextLong()
+	default:
+		t.short = 1
+		return t.nextShort()
+	}
+	return true
+}
+
+// Token returns the most recent token generated by a call to Next.
+func (t *Tokenizer) Token() Token {
+	return t.tok
+}
+
+// Err returns the error that stopped the tokenizer, or nil.
+func (t *Tokenizer) Err() error {
+	return t.err
+}
+
+func (t *Tokenizer) advance(n int) {
+	t.index += n
+}
+
+func (t *Tokenizer) fail(err error) bool {
+	t.err = err
+	return false
+}
+
+func (t *Tokenizer) nextLong() bool {
+	name, value, hasValue := strings.Cut(t.args[t.index], "=")
+	if t.flags&longOnly != 0 && name[0] == '-' {
+		name = canonicalName(name)
+	}
+	name = t.longName(name)
+	kind := t.opts.Kind(name)
+	if kind == Unknown && t.flags&abbreviations != 0 {
+		full, err := t.abbreviation(name)
+		if err != nil {
+			return t.fail(err)
+		}
+		if full != "" {
+			name, kind = full, t.opts.Kind(full)
+		}
+	}
+	return t.long(name, value, hasValue, kind)
+}
+
+// long processes the rest of the current argument as an option of the given
+// name and kind, with the value after = if hasValue.
+func (t *Tokenizer) long(name, value string, hasValue bool, kind Kind) bool {
+	t.tok.Kind = OptionToken
+	t.tok.Name = name
+	t.kind = kind
+	switch kind.Base() {
+	case Required, Property:
+		if hasValue {
+			t.advance(1)
+		} else if t.index+2 > len(t.args) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires an argument", name))
+		} else if t.dashValue(kind, t.args[t.index+1]) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires an argument (got what looks like an option)", name))
+		} else {
+			value = t.args[t.index+1]
+			hasValue = true
+			t.advance(2)
+		}
+	case Optional:
+		t.advance(1)
+	case Boolean, Counter, Toggle:
+		if hasValue && (t.flags&(boolValues|longOnly) == 0 || kind.Base() != Boolean) {
+			return t.fail(errorf(CodeUnexpectedArg, name, "option %s takes no argument", name))
+		}
+		t.advance(1)
+	case TakeTwoArgs:
+		n := kind.NArgs()
+		if hasValue {
+			return t.fail(errorf(CodeUnexpectedArg, name, "option %s takes %s; %s=VALUE form is not permitted", name, arguments(n), name))
+		} else if t.index+1+n > len(t.args) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires %s", name, arguments(n)))
+		}
+		t.tok.Values = t.args[t.index+1 : t.index+1+n : t.index+1+n]
+		t.advance(1 + n)
+		return true
+	case Rest:
+		if hasValue {
+			return t.fail(errorf(CodeUnexpectedArg, name, "option %s takes the remaining arguments; %s=VALUE form is not permitted", name, name))
+		}
+		t.tok.Values = slices.Clip(t.args[t.index+1:])
+		t.index = len(t.args)
+		return true
+	case Terminated:
+		if hasValue {
+			return t.fail(errorf(CodeUnexpectedArg, name, "option %s takes arguments up to %s; %s=VALUE form is not permitted", name, orList(kind.terminators()), name))
+		}
+		n := t.terminated(name, kind)
+		if n < 0 {
+			return false
+		}
+		t.tok.Values = t.args[t.index+1 : t.index+1+n : t.index+1+n]
+		t.advance(1 + n)
+		return true
+	default:
+		return t.unknown(name, t.args[t.index])
+	}
+	t.tok.Value = value
+	t.tok.HasValue = hasValue
+	return true
+}
+
+// nextShort processes the short option at offset t.short in the current
+// argument, indexing into it instead of rewriting it.
+func (t *Tokenizer) nextShort() bool {
+	arg := t.args[t.index]
+	i := t.short
+	names := &shortNames
+	if arg[0] == '+' {
+		names = &plusNames
+	}
+	name := names[arg[i]]
+	if i == 1 {
+		name = arg[:2]
+	}
+	rest := arg[i+1:]
+	t.tok = Token{Kind: OptionToken, Index: t.index, Name: name}
+	t.short = 0
+	kind := t.opts.Kind(name)
+	if kind&Prefix != 0 {
+		full := arg
+		if i > 1 {
+			full = arg[:1] + arg[i:]
+		}
+		name, value, hasValue := strings.Cut(full, "=")
+		return t.long(name, value, hasValue, kind)
+	}
+	t.kind = kind
+	switch kind.Base() {
+	case Boolean, Counter, Toggle:
+		if rest != "" && rest[0] == '-' {
+			return t.fail(errorf(CodeInvalidOption, name, "invalid option '-'"))
+		}
+		if rest != "" {
+			t.short = i + 1
+			return true
+		}
+		t.advance(1)
+	case Required, Property:
+		if rest != "" {
+			t.tok.Value = t.trimEquals(rest)
+			t.advance(1)
+		} else if t.index+2 > len(t.args) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires an argument", name))
+		} else if t.dashValue(kind, t.args[t.index+1]) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires an argument (got what looks like an option)", name))
+		} else {
+			t.tok.Value = t.args[t.index+1]
+			t.advance(2)
+		}
+		t.tok.HasValue = true
+	case Optional:
+		if rest == "" && t.detached() {
+			return t.fail(errorf(CodeUnexpectedArg, name, "option %s takes an optional argument only in the %sVALUE form", name, name))
+		}
+		t.tok.Value = t.trimEquals(rest)
+		t.tok.HasValue = rest != ""
+		t.advance(1)
+	case TakeTwoArgs:
+		n := kind.NArgs()
+		if rest != "" {
+			if t.index+n > len(t.args) {
+				return t.fail(errorf(CodeMissingArg, name, "option %s requires %s", name, arguments(n)))
+			}
+			t.tok.Values = make([]string, n)
+			t.tok.Values[0] = rest
+			copy(t.tok.Values[1:], t.args[t.index+1:t.index+n])
+			t.advance(n)
+		} else if t.index+1+n > len(t.args) {
+			return t.fail(errorf(CodeMissingArg, name, "option %s requires %s", name, arguments(n)))
+		} else {
+			t.tok.Values = t.args[t.index+1 : t.index+1+n : t.index+1+n]
+			t.advance(1 + n)
+		}
+	case Rest:
+		if rest != "" {
+			t.tok.Values = make([]string, len(t.args)-t.index)
+			t.tok.Values[0] = rest
+			copy(t.tok.Values[1:], t.args[t.index+1:])
+		} else {
+			t.tok.Values = slices.Clip(t.args[t.index+1:])
+		}
+		t.index = len(t.args)
+	case Terminated:
+		if slices.Contains(kind.terminators(), rest) {
+			t.tok.Values = []string{rest}
+			t.advance(1)
+			break
+		}
+		n := t.terminated(name, kind)
+		if n < 0 {
+			return false
+		}
+		if rest != "" {
+			t.tok.Values = make([]string, 1+n)
+			t.tok.Values[0] = rest
+			copy(t.tok.Values[1:], t.args[t.index+1:t.index+1+n])
+		} else {
+			t.tok.Values = t.args[t.index+1 : t.index+1+n : t.index+1+n]
+		}
+		t.advance(1 + n)
+	default:
+		if i > 1 {
+			arg = arg[:1] + arg[i:]
+		}
+		return t.unknown(name, arg)
+	}
+	return true
+}
+
+// unknown fails with an unknown option error, or produces arg, the rest of
+// the current argument, as a positional argument in the passthrough mode, or
+// as the first of the remaining arguments, including any --, in the
+// stopAtUnknown mode.
+func (t *Tokenizer) unknown(name, arg string) bool {
+	switch {
+	case t.flags&stopAtUnknown != 0:
+		t.tok = Token{Index: t.index, Value: arg}
+		t.exited = true
+		t.flags |= noDDash
+	case t.flags&passthrough != 0:
+		t.tok = Token{Index: t.index, Value: arg, Unknown: true}
+	default:
+		return t.fail(errorf(CodeUnknownOption, name, "unknown option %q", name))
+	}
+	t.advance(1)
+	return true
+}
+
+// dashValue reports whether value, given as the next argument, is rejected
+// by the NoDashValue modifier of kind.
+func (t *Tokenizer) dashValue(kind Kind, value string) bool {
+	if kind&NoDashValue == 0 || len(value) < 2 || value[0] != '-' {
+		return false
+	}
+	return t.flags&negativeNumbers == 0 || !isNegative(value)
+}
+
+// option reports whether arg starts like an option: with one of the custom
+// prefixes if any, and otherwise with a dash or, in the PlusOptions mode, a
+// plus sign.
+func (t *Tokenizer) option(arg string) bool {
+	if t.prefixes != nil {
+		return t.prefix(arg) != ""
+	}
+	return strings.HasPrefix(arg, "-") || t.plus(arg)
+}
+
+// prefix returns the longest of the custom prefixes that arg starts with and
+// is longer than, or "".
+func (t *Tokenizer) prefix(arg string) string {
+	var longest string
+	for _, prefix := range t.prefixes {
+		if len(prefix) > len(longest) && len(arg) > len(prefix) && strings.HasPrefix(arg, prefix) {
+			longest = prefix
+		}
+	}
+	return longest
+}
+
+// nextPrefixed processes an option introduced by one of the custom prefixes.
+func (t *Tokenizer) nextPrefixed(arg string) bool {
+	switch t.prefix(arg) {
+	case "-":
+		if t.unbundled(arg) || t.flags&singleDashLong != 0 && t.whole(arg) {
+			return t.nextLong()
+		}
+	case "+":
+		if t.unbundled(arg) || t.whole(arg) {
+			return t.nextLong()
+		}
+	default:
+		return t.nextLong()
+	}
+	t.short = 1
+	return t.nextShort()
+}
+
+// unbundled reports whether arg, starting with a single dash or a plus sign,
+// is a single option in the noBundling or longOnly mode.
+func (t *Tokenizer) unbundled(arg string) bool {
+	return t.flags&longOnly != 0 || t.flags&noBundling != 0 && len(arg) > 2
+}
+
+// plus reports whether arg is an option starting with a plus sign in the
+// PlusOptions mode.
+func (t *Tokenizer) plus(arg string) bool {
+	return t.flags&plusOptions != 0 && len(arg) > 1 && arg[0] == '+'
+}
+
+// whole reports whether arg, longer than a short option, is known to Kind as
+// a single option.
+func (t *Tokenizer) whole(arg string) bool {
+	if len(arg) <= 2 {
+		return false
+	}
+	name, _, _ := strings.Cut(arg, "=")
+	return t.opts.Kind(t.longName(name)) != Unknown
+}
+
+// canonicalName returns the name of an option given with one or two dashes in
+// the longOnly mode: -x for a single character, and --name otherwise.
+func canonicalName(name string) string {
+	rest := strings.TrimPrefix(name[1:], "-")
+	switch {
+	case len(rest) == 1:
+		return name[len(name)-2:]
+	case name[1] == '-':
+		return name
+	default:
+		return "-" + name
+	}
+}
+
+// longName returns the name of a long option, lowercased in the ignoreCase
+// mode.
+func (t *Tokenizer) longName(name string) string {
+	if t.flags&ignoreCase != 0 {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// operand reports whether arg, starting with a dash, is a positional argument
+// in the DigitOptions or NegativeNumbers mode.
+func (t *Tokenizer) operand(arg string) bool {
+	switch {
+	case t.flags&digitOptions != 0 && '0' <= arg[1] && arg[1] <= '9':
+	case t.flags&negativeNumbers != 0 && isNegative(arg):
+	default:
+		return false
+	}
+	return t.opts.Kind(arg[:2]) == Unknown
+}
+
+// isNegative reports whether s is a negative decimal number such as -5 or
+// -3.14.
+func isNegative(s string) bool {
+	if len(s) < 2 || s[0] != '-' || !('0' <= s[1] && s[1] <= '9' || s[1] == '.') {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil || errors.Is(err, strconv.ErrRange)
+}
+
+// trimEquals removes the = from a value attached to a short option as in
+// -n=42, in the ShortEquals mode.
+func (t *Tokenizer) trimEquals(value string) string {
+	if t.flags&shortEquals != 0 {
+		return strings.TrimPrefix(value, "=")
+	}
+	return value
+}
+
+// detached reports whether, in the StrictOptional mode, the argument
+// following the current one looks like a detached value of a short Optional
+// option.
+func (t *Tokenizer) detached() bool {
+	if t.flags&strictOptional == 0 || t.index+1 >= len(t.args) {
+		return false
+	}
+	next := t.args[t.index+1]
+	return next == "-" || !strings.HasPrefix(next, "-")
+}
+
+// terminated returns the number of arguments following the current one up to
+// and including the terminator of kind, or -1 after failing if there is no
+// terminator.
+func (t *Tokenizer) terminated(name string, kind Kind) int {
+	terms := kind.terminators()
+	for j, arg := range t.args[t.index+1:] {
+		if slices.Contains(terms, arg) {
+			return j + 1
+		}
+	}
+	t.fail(errorf(CodeMissingArg, name, "option %s requires arguments terminated by %s", name, orList(terms)))
+	return -1
+}
+
+// orList returns the quoted values joined with "or" for error messages.
+func orList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	return strings.Join(quoted, " or ")
+}
+
+// arguments returns "an argument" or "N arguments" for error messages.
+func arguments(n int) string {
+	if n == 1 {
+		return "an argument"
+	}
+	return strconv.Itoa(n) + " arguments"
+}
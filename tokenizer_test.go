@@ -0,0 +1,91 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func (l Token) Equal(r Token) bool {
+	return l.Kind == r.Kind && l.Index == r.Index && l.Name == r.Name &&
+		l.Value == r.Value && l.HasValue == r.HasValue &&
+		slices.Equal(l.Values, r.Values) && l.AfterDDash == r.AfterDDash
+}
+
+func TestTokenize(t *testing.T) {
+	args := []string{"-ab", "-rval", "x", "--optional", "-sk", "v", "--set", "k2", "v2", "--", "-a"}
+	var tokens []Token
+	tz := Tokenize(&TestOptions{}, args)
+	for tz.Next() {
+		tokens = append(tokens, tz.Token())
+	}
+	if err := tz.Err(); err != nil {
+		t.Fatalf("Err(): unexpected error: %v", err)
+	}
+	CompareSliceF(t, "tokens", tokens, []Token{
+		{Kind: OptionToken, Index: 0, Name: "-a"},
+		{Kind: OptionToken, Index: 0, Name: "-b"},
+		{Kind: OptionToken, Index: 1, Name: "-r", Value: "val", HasValue: true},
+		{Kind: PositionalToken, Index: 2, Value: "x"},
+		{Kind: OptionToken, Index: 3, Name: "--optional"},
+		{Kind: OptionToken, Index: 4, Name: "-s", Values: []string{"k", "v"}},
+		{Kind: OptionToken, Index: 6, Name: "--set", Values: []string{"k2", "v2"}},
+		{Kind: DDashToken, Index: 9},
+		{Kind: PositionalToken, Index: 10, Value: "-a", AfterDDash: true},
+	})
+}
+
+func TestTokenizeError(t *testing.T) {
+	tz := Tokenize(&TestOptions{}, []string{"-a", "--unknown", "-b"})
+	var n int
+	for tz.Next() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("expected 1 token before the error, got %d", n)
+	}
+	if err := tz.Err(); err == nil || err.Error() != `unknown option "--unknown"` {
+		t.Errorf("Err() = %v, want unknown option error", err)
+	}
+	if tz.Next() {
+		t.Errorf("Next() returned true after an error")
+	}
+}
+
+func tokenizerArgs() []string {
+	args := make([]string, 0, 10000)
+	for i := range 2500 {
+		args = append(args, "-abc", "--required", strconv.Itoa(i), "file")
+	}
+	return args
+}
+
+func TestTokenizerAllocs(t *testing.T) {
+	args := tokenizerArgs()
+	opts := &TestOptions{}
+	allocs := testing.AllocsPerRun(10, func() {
+		tz := Tokenize(opts, args)
+		for tz.Next() {
+			_ = tz.Token()
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most 1 allocation, got %v", allocs)
+	}
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	args := tokenizerArgs()
+	opts := &TestOptions{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		tz := Tokenize(opts, args)
+		for tz.Next() {
+			_ = tz.Token()
+		}
+	}
+}
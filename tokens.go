@@ -0,0 +1,28 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"iter"
+)
+
+// Tokens returns an iterator over the tokens of the argument list, which
+// should not include the command name, for callers driving their own state
+// machines, e.g. for order-sensitive options such as the predicates of
+// find(1). Like Tokenize, it consults only the Kind method of opts. If the
+// command line is invalid, the last pair yielded is a zero Token and the
+// error.
+func Tokens(opts Options, args []string) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		t := Tokenize(opts, args)
+		for t.Next() {
+			if !yield(t.Token(), nil) {
+				return
+			}
+		}
+		if err := t.Err(); err != nil {
+			yield(Token{}, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	var names []string
+	for tok, err := range Tokens(&TestOptions{}, []string{"-ab", "x", "--required", "v", "--", "-c"}) {
+		if err != nil {
+			t.Fatalf("Tokens(): unexpected error: %v", err)
+		}
+		switch tok.Kind {
+		case OptionToken:
+			names = append(names, tok.Name+"="+tok.Value)
+		case PositionalToken:
+			names = append(names, tok.Value)
+		case DDashToken:
+			names = append(names, "--")
+		}
+	}
+	CompareSlice(t, "tokens", names, []string{"-a=", "-b=", "x", "--required=v", "--", "-c"})
+
+	var n int
+	for range Tokens(&TestOptions{}, []string{"-a", "-b", "-c"}) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Tokens(): break must stop the iteration")
+	}
+
+	var last error
+	for _, err := range Tokens(&TestOptions{}, []string{"-a", "--unknown", "-b"}) {
+		last = err
+	}
+	if Code(last) != CodeUnknownOption {
+		t.Errorf("Tokens(): expected %s, but got %v", CodeUnknownOption, last)
+	}
+}
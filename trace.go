@@ -0,0 +1,123 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+)
+
+// kindTracer logs the results of the Kind method of the wrapped Options.
+type kindTracer struct {
+	Options
+	logger *slog.Logger
+}
+
+func (k kindTracer) Kind(name string) Kind {
+	kind := k.Options.Kind(name)
+	k.logger.LogAttrs(context.Background(), slog.LevelDebug, "kind", slog.String("name", name), slog.String("kind", kind.String()))
+	return kind
+}
+
+// traceToken logs tok and the error of its handler. The values of a secret
+// option are redacted.
+func (p *Parser) traceToken(tok *Token, secret bool, err error) {
+	attrs := []slog.Attr{slog.String("kind", tok.Kind.String()), slog.Int("index", tok.Index)}
+	switch {
+	case tok.Kind == PositionalToken:
+		attrs = append(attrs, slog.String("value", tok.Value), slog.Bool("afterDDash", tok.AfterDDash))
+	case tok.Kind == OptionToken && tok.Values != nil && secret:
+		attrs = append(attrs, slog.String("name", tok.Name), slog.Any("values", redactValues(tok.Values)))
+	case tok.Kind == OptionToken && tok.Values != nil:
+		attrs = append(attrs, slog.String("name", tok.Name), slog.Any("values", tok.Values))
+	case tok.Kind == OptionToken && tok.HasValue && secret:
+		attrs = append(attrs, slog.String("name", tok.Name), slog.String("value", redacted))
+	case tok.Kind == OptionToken && tok.HasValue:
+		attrs = append(attrs, slog.String("name", tok.Name), slog.String("value", tok.Value))
+	case tok.Kind == OptionToken:
+		attrs = append(attrs, slog.String("name", tok.Name))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	p.Logger.LogAttrs(context.Background(), slog.LevelDebug, "token", attrs...)
+}
+
+// ParseTrace is a structured record of a parse, which can be serialized to
+// JSON and attached to bug reports.
+type ParseTrace struct {
+	// Args is the argument list given to the parser.
+	Args []string `json:"args"`
+
+	// Events are the parsing decisions, in order.
+	Events []TraceEvent `json:"events"`
+
+	// Positional is the result of the parse.
+	Positional []string `json:"positional"`
+
+	// Error and Code describe the error of the parse, if any.
+	Error string    `json:"error,omitempty"`
+	Code  ErrorCode `json:"code,omitempty"`
+}
+
+// TraceEvent is a parsing decision logged by a Parser with a Logger. Event is
+// the message ("kind", "token", "args" or "error") and Attrs are the
+// attributes of the log record.
+type TraceEvent struct {
+	Event string         `json:"event"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// TraceParse parses the argument list like Parse while recording a
+// ParseTrace. The trace is returned even if the parse fails. The values of
+// the options reported secret by OptionsWithSecret are redacted.
+func TraceParse(opts Options, args []string) (*ParseTrace, error) {
+	trace := &ParseTrace{}
+	p := &Parser{Logger: slog.New(traceHandler{trace})}
+	positional, err := p.Parse(opts, args)
+	trace.Args = redactArgs(opts, args)
+	trace.Positional = positional
+	if err != nil {
+		trace.Error = err.Error()
+		trace.Code = Code(err)
+	}
+	return trace, err
+}
+
+// WriteJSON writes the trace to w as indented JSON.
+func (t *ParseTrace) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// traceHandler is a slog.Handler that appends the records to a ParseTrace.
+type traceHandler struct {
+	trace *ParseTrace
+}
+
+func (h traceHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h traceHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h traceHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h traceHandler) Handle(_ context.Context, r slog.Record) error {
+	ev := TraceEvent{Event: r.Message}
+	r.Attrs(func(a slog.Attr) bool {
+		if ev.Attrs == nil {
+			ev.Attrs = make(map[string]any)
+		}
+		switch v := a.Value.Any().(type) {
+		case error:
+			ev.Attrs[a.Key] = v.Error()
+		case nil:
+		default:
+			ev.Attrs[a.Key] = v
+		}
+		return true
+	})
+	h.trace.Events = append(h.trace.Events, ev)
+	return nil
+}
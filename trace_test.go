@@ -0,0 +1,131 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	var sb strings.Builder
+	handler := slog.NewTextHandler(&sb, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	p := &Parser{Logger: slog.New(handler)}
+	if _, err := p.Parse(&TestOptions{}, []string{"-ar", "x", "y", "--", "z", "-b"}); err != nil {
+		t.Fatalf("Parse(): unexpected error: %v", err)
+	}
+	if _, err := p.Parse(&TestOptions{}, []string{"--boolean=x"}); err == nil {
+		t.Fatalf("Parse(): expected error")
+	}
+	expected := `msg=kind name=-a kind=Boolean
+msg=token kind=option index=0 name=-a
+msg=kind name=-r kind=Required
+msg=token kind=option index=0 name=-r value=x
+msg=token kind=positional index=2 value=y afterDDash=false
+msg=token kind=ddash index=3
+msg=token kind=positional index=4 value=z afterDDash=true
+msg=token kind=positional index=5 value=-b afterDDash=true
+msg=args before=[y] after="[z -b]" error=<nil>
+msg=kind name=--boolean kind=Boolean
+msg=error index=0 error="option --boolean takes no argument"
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected trace:\n%s", sb.String())
+	}
+}
+
+func TestKindString(t *testing.T) {
+	for kind, want := range map[Kind]string{
+		Unknown:                "Unknown",
+		TakeTwoArgs:            "TakeTwoArgs",
+		Counter:                "Counter",
+		Rest:                   "Rest",
+		Property:               "Property",
+		Toggle:                 "Toggle",
+		TerminatedBy(";", "+"): `TerminatedBy(";", "+")`,
+		TakeNArgs(2):           "TakeTwoArgs",
+		TakeNArgs(4):           "TakeNArgs(4)",
+		Kind(100):              "Kind(100)",
+	} {
+		if got := kind.String(); got != want {
+			t.Errorf("Kind(%d).String() = %q, want %q", int(kind), got, want)
+		}
+	}
+}
+
+func TestTraceParse(t *testing.T) {
+	trace, err := TraceParse(&TestOptions{}, []string{"-r", "x", "y", "--bogus"})
+	if err == nil {
+		t.Fatalf("TraceParse(): expected error")
+	}
+	var sb strings.Builder
+	if err := trace.WriteJSON(&sb); err != nil {
+		t.Fatalf("WriteJSON(): unexpected error: %v", err)
+	}
+	expected := `{
+  "args": [
+    "-r",
+    "x",
+    "y",
+    "--bogus"
+  ],
+  "events": [
+    {
+      "event": "kind",
+      "attrs": {
+        "kind": "Required",
+        "name": "-r"
+      }
+    },
+    {
+      "event": "token",
+      "attrs": {
+        "index": 0,
+        "kind": "option",
+        "name": "-r",
+        "value": "x"
+      }
+    },
+    {
+      "event": "token",
+      "attrs": {
+        "afterDDash": false,
+        "index": 2,
+        "kind": "positional",
+        "value": "y"
+      }
+    },
+    {
+      "event": "kind",
+      "attrs": {
+        "kind": "Unknown",
+        "name": "--bogus"
+      }
+    },
+    {
+      "event": "error",
+      "attrs": {
+        "error": "unknown option \"--bogus\"",
+        "index": 3
+      }
+    }
+  ],
+  "positional": null,
+  "error": "unknown option \"--bogus\"",
+  "code": "E_UNKNOWN_OPTION"
+}
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected trace:\n%s", sb.String())
+	}
+}
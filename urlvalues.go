@@ -0,0 +1,199 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseValues parses options from url.Values, such as a parsed query string
+// or form, so that web front-ends and RPC endpoints can reuse the options
+// of a command-line interface.
+//
+// A key of a single character x is the option -x, and a longer key is the
+// option --key. Keys starting with a dash are used as-is. The keys are
+// processed in sorted order, and values in order.
+//
+// A Boolean option is specified by an empty value or a true boolean value
+// (as accepted by strconv.ParseBool), and skipped by a false one. An Optional
+// option with an empty value has no value. A Counter option is counted like
+// a Boolean option, and so is each occurrence of a Toggle option. A
+// TakeTwoArgs or TakeNArgs(n) option takes its values in groups of two or n,
+// and a Rest option takes all of its values at once. A TerminatedBy option
+// takes its values in groups ended by a terminator.
+//
+// The options are checked as by Parse: a Once option must occur once, the
+// values of a File or NewFile option must name files, and a Deprecated option
+// is reported to the Warning method of opts.
+func ParseValues(opts Options, values url.Values) error {
+	return new(Parser).ParseValues(opts, values)
+}
+
+// ParseValues is like the package-level ParseValues, but resolves the values
+// with the Resolvers of p and records the warnings returned by Warnings.
+func (p *Parser) ParseValues(opts Options, values url.Values) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	p.warnings = nil
+	h := newHandlers(opts)
+	var st optionState
+	for _, key := range keys {
+		name := key
+		switch {
+		case strings.HasPrefix(key, "-"):
+		case len(key) == 1:
+			name = "-" + key
+		default:
+			name = "--" + key
+		}
+		vs := values[key]
+		kind := opts.Kind(name)
+		// occur checks an occurrence of the option and passes its values to
+		// handle.
+		occur := func(values []string, handle func(values []string) error) error {
+			err := st.checkOnce(name, kind)
+			if err == nil {
+				values, err = p.checkValues(name, kind, values)
+			}
+			if err == nil {
+				err = handle(values)
+			}
+			if err != nil && !p.warn(&h, err) {
+				return err
+			}
+			if err == nil && kind&Deprecated != 0 {
+				p.warn(&h, deprecationWarning(opts, name))
+			}
+			return nil
+		}
+		switch kind.Base() {
+		case Boolean:
+			for _, value := range vs {
+				if value != "" {
+					b, err := strconv.ParseBool(value)
+					if err != nil {
+						return errorf(CodeUnexpectedArg, name, "option %s takes no argument", name)
+					}
+					if !b {
+						continue
+					}
+				}
+				if err := occur(nil, func([]string) error {
+					return h.option(name, "", false, nil)
+				}); err != nil {
+					return err
+				}
+			}
+		case Counter:
+			count := 0
+			for _, value := range vs {
+				if b, err := strconv.ParseBool(value); value != "" && err != nil {
+					return errorf(CodeUnexpectedArg, name, "option %s takes no argument", name)
+				} else if value == "" || b {
+					count++
+				}
+			}
+			for range count - 1 {
+				if err := st.checkOnce(name, kind); err != nil {
+					return err
+				}
+			}
+			if count > 0 {
+				if err := occur(nil, func([]string) error {
+					return h.count(name, count)
+				}); err != nil {
+					return err
+				}
+			}
+		case Toggle:
+			index := 0
+			for _, value := range vs {
+				if b, err := strconv.ParseBool(value); value != "" && err != nil {
+					return errorf(CodeUnexpectedArg, name, "option %s takes no argument", name)
+				} else if value != "" && !b {
+					continue
+				}
+				if err := occur(nil, func([]string) error {
+					return h.toggle(name, index, nil)
+				}); err != nil {
+					return err
+				}
+				index++
+			}
+		case Required:
+			for _, value := range vs {
+				if err := occur([]string{value}, func(values []string) error {
+					return h.option(name, values[0], true, nil)
+				}); err != nil {
+					return err
+				}
+			}
+		case Property:
+			for _, value := range vs {
+				if err := occur([]string{value}, func(values []string) error {
+					return h.property(name, values[0], nil)
+				}); err != nil {
+					return err
+				}
+			}
+		case Optional:
+			for _, value := range vs {
+				var values []string
+				if value != "" {
+					values = []string{value}
+				}
+				if err := occur(values, func(values []string) error {
+					if len(values) == 0 {
+						return h.option(name, "", false, nil)
+					}
+					return h.option(name, values[0], true, nil)
+				}); err != nil {
+					return err
+				}
+			}
+		case TakeTwoArgs:
+			n := kind.NArgs()
+			if len(vs)%n != 0 {
+				return errorf(CodeMissingArg, name, "option %s requires %s", name, arguments(n))
+			}
+			for i := 0; i < len(vs); i += n {
+				if err := occur(vs[i:i+n:i+n], func(values []string) error {
+					return h.optionN(name, values)
+				}); err != nil {
+					return err
+				}
+			}
+		case Rest:
+			if err := occur(slices.Clip(vs), func(values []string) error {
+				return h.optionN(name, values)
+			}); err != nil {
+				return err
+			}
+		case Terminated:
+			terms := kind.terminators()
+			for len(vs) > 0 {
+				n := slices.IndexFunc(vs, func(v string) bool { return slices.Contains(terms, v) }) + 1
+				if n == 0 {
+					return errorf(CodeMissingArg, name, "option %s requires arguments terminated by %s", name, orList(terms))
+				}
+				if err := occur(vs[:n:n], func(values []string) error {
+					return h.optionN(name, values)
+				}); err != nil {
+					return err
+				}
+				vs = vs[n:]
+			}
+		default:
+			return errorf(CodeUnknownOption, name, "unknown option %q", name)
+		}
+	}
+	return nil
+}
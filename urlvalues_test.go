@@ -0,0 +1,98 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParseValues(t *testing.T) {
+	values, err := url.ParseQuery("a&b=true&c=false&boolean=1&r=val1&required=val2&required=&o&optional=val3&s=k1&s=v1&set=k2&set=v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &TestOptions{}
+	if err := ParseValues(opts, values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", opts.OptionHistory, []OptionCall{
+		{Name: "-a"},
+		{Name: "-b"},
+		{Name: "--boolean"},
+		{Name: "-o"},
+		{Name: "--optional", Value: "val3", HasValue: true},
+		{Name: "-r", Value: "val1", HasValue: true},
+		{Name: "--required", Value: "val2", HasValue: true},
+		{Name: "--required", Value: "", HasValue: true},
+	})
+	CompareSliceF(t, "OptionNHistory", opts.OptionNHistory, []OptionNCall{
+		{Name: "-s", Values: []string{"k1", "v1"}},
+		{Name: "--set", Values: []string{"k2", "v2"}},
+	})
+
+	for query, target := range map[string]error{
+		"a=yes":        ErrCmdline,
+		"set=k":        ErrCmdline,
+		"unknown=x":    ErrCmdline,
+		"number=NaN":   strconv.ErrSyntax,
+		"help":         ErrHelp,
+		"--version=1":  ErrVersion,
+		"--number=NaN": strconv.ErrSyntax,
+	} {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ParseValues(&TestOptions{}, values); !errors.Is(err, target) {
+			t.Errorf("%s: expected %v, got %#v", query, target, err)
+		}
+	}
+}
+
+func TestParseValuesChecks(t *testing.T) {
+	if err := ParseValues(&onceOptions{}, url.Values{"output": {"a", "b"}}); Code(err) != CodeRepeatedOption {
+		t.Errorf("expected %s, but got %v", CodeRepeatedOption, err)
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.WriteFile(input, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "output")
+	opts := &fileOptions{}
+	if err := ParseValues(opts, url.Values{"input": {output}}); Code(err) != CodeInvalidValue || len(opts.OptionHistory) != 0 {
+		t.Errorf("expected %s, but got %v", CodeInvalidValue, err)
+	}
+	if err := ParseValues(opts, url.Values{"pair": {input, output}}); Code(err) != CodeInvalidValue || len(opts.OptionNHistory) != 0 {
+		t.Errorf("expected %s, but got %v", CodeInvalidValue, err)
+	}
+
+	dopts := &deprecatedOptions{}
+	p := &Parser{Resolvers: map[string]Resolver{
+		"env:": ResolverFunc(func(_ context.Context, ref string) (string, error) {
+			return "resolved", nil
+		}),
+	}}
+	if err := p.ParseValues(dopts, url.Values{"old": {""}, "legacy": {"env:X"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	CompareSlice(t, "OptionHistory", dopts.OptionHistory, []OptionCall{
+		{Name: "--legacy", Value: "resolved", HasValue: true},
+		{Name: "--old"},
+	})
+	CompareSlice(t, "Warnings", dopts.Warnings, []string{
+		"option --legacy is deprecated",
+		"option --old is deprecated; use -a",
+	})
+	if warnings := p.Warnings(); len(warnings) != 2 {
+		t.Errorf("Warnings() = %v", warnings)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"os"
+)
+
+// Validate checks the argument list against the grammar described by the
+// Kind method of opts, as Parse would, without calling any other method of
+// opts. It reports unknown options, missing arguments and malformed options,
+// so that command lines can be linted without side effects. Like Parse, it
+// also reports repeated Once options and values of File and NewFile options
+// that do not name files.
+func Validate(opts Options, args []string) error {
+	var st optionState
+	t := Tokenizer{opts: opts, args: args}
+	for t.Next() {
+		if t.tok.Kind == OptionToken {
+			if err := checkToken(&st, &t); err != nil {
+				return err
+			}
+		}
+	}
+	return t.err
+}
+
+// checkToken performs the checks of Parse that do not call opts on the
+// current OptionToken of t.
+func checkToken(st *optionState, t *Tokenizer) error {
+	var p Parser
+	tok := &t.tok
+	err := st.checkOnce(tok.Name, t.kind)
+	if err == nil && tok.Values != nil {
+		_, err = p.checkValues(tok.Name, t.kind, tok.Values)
+	} else if err == nil && tok.HasValue {
+		_, err = p.checkValue(tok.Name, t.kind, tok.Value)
+	}
+	return err
+}
+
+// Validate checks the argument list as Parse would, including subcommands,
+// permitted values of options, Max, and required options, groups and
+// dependencies, without recording the values or calling Func. Environment
+// variables listed in Env count as specifying their options.
+func (s *Spec) Validate(args []string) error {
+	s.init()
+	counts := make(map[*OptionSpec]int)
+	cmd := s
+	for {
+		var flags int
+		if len(cmd.Commands) > 0 {
+			flags = earlyExit | noDDash
+		}
+		next := -1
+		var st optionState
+		t := Tokenizer{opts: cmd, args: args, flags: flags}
+		for t.Next() {
+			tok := &t.tok
+			if tok.Kind == PositionalToken && flags != 0 {
+				next = tok.Index
+				break
+			}
+			if tok.Kind != OptionToken {
+				continue
+			}
+			if err := checkToken(&st, &t); err != nil {
+				return err
+			}
+			o := cmd.Lookup(tok.Name)
+			if o == nil {
+				continue
+			}
+			values := tok.Values
+			if tok.HasValue {
+				values = []string{tok.Value}
+			}
+			if err := o.check(values); err != nil {
+				return wrapOptionError(tok.Name, err)
+			}
+			if err := o.checkMax(tok.Name, counts[o]); err != nil {
+				return wrapOptionError(tok.Name, err)
+			}
+			counts[o]++
+		}
+		if t.err != nil {
+			return t.err
+		}
+		if flags == 0 {
+			break
+		}
+		if next < 0 {
+			return ErrNoSubcommand
+		}
+		sub := cmd.Command(args[next])
+		if sub == nil {
+			return errorf(CodeUnknownCommand, "", "unknown command %q", args[next])
+		}
+		cmd, args = sub, args[next+1:]
+	}
+
+	specified := func(o *OptionSpec) bool { return counts[o] > 0 || o.hasEnv() }
+	for c := cmd; c != nil; c = c.parent {
+		for _, o := range c.Options {
+			if o.Required && !specified(o) {
+				return errorf(CodeMissingOption, o.Names[0], "option %s is required", o.Names[0])
+			}
+		}
+		if err := c.checkRequires(specified); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OptionSpec) hasEnv() bool {
+	for _, env := range o.Env {
+		if _, ok := os.LookupEnv(env); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	opts := &TestOptions{}
+	if err := Validate(opts, []string{"-ar", "x", "--number", "NaN", "--set", "k", "v", "y"}); err != nil {
+		t.Errorf("Validate(): unexpected error: %v", err)
+	}
+	if len(opts.OptionHistory) != 0 || len(opts.OptionNHistory) != 0 || len(opts.ArgHistory) != 0 {
+		t.Errorf("Validate() called the handlers")
+	}
+	if err := Validate(opts, []string{"-a", "--set", "k"}); Code(err) != CodeMissingArg {
+		t.Errorf("Validate(): expected %s, but got %v", CodeMissingArg, err)
+	}
+	if err := Validate(&onceOptions{}, []string{"-O", "a", "-O", "b"}); Code(err) != CodeRepeatedOption {
+		t.Errorf("Validate(): expected %s, but got %v", CodeRepeatedOption, err)
+	}
+	if err := Validate(&fileOptions{}, []string{"--input", filepath.Join(t.TempDir(), "missing")}); Code(err) != CodeInvalidValue {
+		t.Errorf("Validate(): expected %s, but got %v", CodeInvalidValue, err)
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	tests := []struct {
+		args []string
+		code ErrorCode
+	}{
+		{[]string{"--color=always", "run", "-n", "--", "ls", "-l"}, ""},
+		{[]string{"-f"}, CodeMissingArg},
+		{[]string{"--color=sometimes", "run"}, CodeInvalidValue},
+		{[]string{"-v"}, CodeNoSubcommand},
+		{[]string{"walk"}, CodeUnknownCommand},
+		{[]string{"run", "-x"}, CodeUnknownOption},
+	}
+	for _, tt := range tests {
+		spec := newTestSpec()
+		spec.Options[0].Func = func(name string, values []string) error {
+			t.Errorf("%q: Func called", tt.args)
+			return nil
+		}
+		err := spec.Validate(tt.args)
+		if Code(err) != tt.code {
+			t.Errorf("%q: expected code %q, but got %v", tt.args, tt.code, err)
+		}
+		if spec.Options[2].Count() != 0 {
+			t.Errorf("%q: values recorded", tt.args)
+		}
+	}
+
+	spec := newTestSpec()
+	spec.Commands[0].Options[0].Required = true
+	if err := spec.Validate([]string{"run"}); Code(err) != CodeMissingOption {
+		t.Errorf("expected %s, but got %v", CodeMissingOption, err)
+	}
+	spec.Commands[0].Options[0].Env = []string{"EXAMPLE_DRY_RUN"}
+	t.Setenv("EXAMPLE_DRY_RUN", "1")
+	if err := spec.Validate([]string{"run"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSpecValidateConstraints(t *testing.T) {
+	newSpec := func() *Spec {
+		return &Spec{
+			Name: "constraints",
+			Options: []*OptionSpec{
+				{Names: []string{"-a"}},
+				{Names: []string{"-b"}},
+				{Names: []string{"-o"}, Kind: Required, Max: 1},
+				{Names: []string{"--key"}, Kind: Required, Requires: []string{"--cert"}},
+				{Names: []string{"--cert"}, Kind: Required},
+			},
+			RequireOneOf: [][]string{{"-a", "-b"}},
+		}
+	}
+	tests := []struct {
+		args []string
+		code ErrorCode
+	}{
+		{[]string{"-a", "-o", "x"}, ""},
+		{[]string{"-a", "-o", "x", "-o", "y"}, CodeRepeatedOption},
+		{[]string{"-a", "--key", "k"}, CodeMissingOption},
+		{[]string{"-b", "--key", "k", "--cert", "c"}, ""},
+		{nil, CodeMissingOption},
+	}
+	for _, tt := range tests {
+		if err := newSpec().Validate(tt.args); Code(err) != tt.code {
+			t.Errorf("Validate(%q): expected code %q, but got %v", tt.args, tt.code, err)
+		}
+		if _, _, err := newSpec().Parse(tt.args); Code(err) != tt.code {
+			t.Errorf("Parse(%q): expected code %q, but got %v", tt.args, tt.code, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"errors"
+	"strings"
+)
+
+// Issue is a problem found by Vet.
+type Issue struct {
+	// Name is the option name the issue is about, or "".
+	Name string
+
+	// Message describes the issue.
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Name == "" {
+		return i.Message
+	}
+	return i.Name + ": " + i.Message
+}
+
+const vetProbe = "--options-vet-probe"
+
+// Vet probes opts for common implementation mistakes, given the option names
+// (including dashes) it is supposed to accept. It reports:
+//
+//   - names that cannot be parsed as an option, such as "-ab" or "x";
+//   - names for which Kind returns Unknown or an undefined Kind;
+//   - Kind returning TakeTwoArgs, TakeNArgs, Rest or TerminatedBy without
+//     OptionN being implemented;
+//   - Option or OptionN returning ErrUnknown for a name Kind accepts;
+//   - Boolean and Optional options that fail when called without a value,
+//     which suggests that they read the value;
+//   - Kind returning both DashValue and NoDashValue;
+//   - Kind returning File or NewFile for an option that takes no value;
+//   - Kind accepting a name it is not supposed to.
+//
+// Vet calls the Option and OptionN methods, so it should be run on a fresh
+// instance in tests. Errors wrapping ErrHelp or ErrVersion are not reported.
+func Vet(opts Options, knownNames []string) []Issue {
+	var issues []Issue
+	report := func(name, msg string) {
+		issues = append(issues, Issue{name, msg})
+	}
+	nopts, hasOptionN := opts.(OptionsWithOptionN)
+	for _, name := range knownNames {
+		if !isOptionName(name) {
+			report(name, "not a valid option name")
+			continue
+		}
+		var err error
+		kind := opts.Kind(name)
+		if kind&(DashValue|NoDashValue) == DashValue|NoDashValue {
+			report(name, "Kind returns both DashValue and NoDashValue")
+		}
+		if kind&(File|NewFile) != 0 {
+			switch kind.Base() {
+			case Boolean, Counter, Toggle:
+				report(name, "Kind returns File or NewFile for an option without a value")
+			}
+		}
+		switch kind.Base() {
+		case Unknown:
+			report(name, "Kind returns Unknown")
+			continue
+		case Boolean:
+			err = opts.Option(name, "", false)
+			if isVetFailure(err) {
+				report(name, "Option fails without a value, but Kind returns Boolean: "+err.Error())
+			}
+		case Counter:
+			if kopts, ok := opts.(OptionsWithCount); ok {
+				err = kopts.OptionCount(name, 1)
+			} else {
+				err = opts.Option(name, "1", true)
+			}
+		case Toggle:
+			if topts, ok := opts.(OptionsWithToggle); ok {
+				err = topts.OptionToggle(name, true, 0)
+			} else {
+				err = opts.Option(name, "true", true)
+			}
+		case Required:
+			err = opts.Option(name, "1", true)
+		case Property:
+			if popts, ok := opts.(OptionsWithProperty); ok {
+				err = popts.OptionProperty(name, "1", "1", true)
+			} else {
+				err = opts.Option(name, "1=1", true)
+			}
+		case Optional:
+			err = opts.Option(name, "", false)
+			if isVetFailure(err) {
+				report(name, "Option fails without a value, but Kind returns Optional: "+err.Error())
+			}
+		case TakeTwoArgs:
+			if !hasOptionN {
+				report(name, "Kind returns "+kind.String()+", but OptionN is not implemented")
+				continue
+			}
+			values := make([]string, kind.NArgs())
+			for i := range values {
+				values[i] = "1"
+			}
+			err = nopts.OptionN(name, values)
+		case Rest:
+			if !hasOptionN {
+				report(name, "Kind returns Rest, but OptionN is not implemented")
+				continue
+			}
+			err = nopts.OptionN(name, []string{"1"})
+		case Terminated:
+			terms := kind.terminators()
+			if terms == nil {
+				report(name, "Kind returns undefined "+kind.String())
+				continue
+			}
+			if !hasOptionN {
+				report(name, "Kind returns "+kind.String()+", but OptionN is not implemented")
+				continue
+			}
+			err = nopts.OptionN(name, []string{"1", terms[0]})
+		default:
+			report(name, "Kind returns undefined "+kind.String())
+			continue
+		}
+		if errors.Is(err, ErrUnknown) {
+			report(name, "Kind accepts the option, but the handler returns ErrUnknown")
+		}
+	}
+	if kind := opts.Kind(vetProbe); kind != Unknown {
+		report(vetProbe, "Kind returns "+kind.String()+" for an unknown option")
+	}
+	return issues
+}
+
+func isOptionName(name string) bool {
+	if strings.HasPrefix(name, "--") {
+		return len(name) > 2 && !strings.Contains(name, "=")
+	}
+	return len(name) == 2 && name[0] == '-' && name[1] != '-'
+}
+
+func isVetFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrHelp) && !errors.Is(err, ErrVersion)
+}
+
+// VetPOSIX checks the design of opts against the Utility Syntax Guidelines
+// of POSIX, given the option names (including dashes) it accepts, for
+// programs targeting strict POSIX environments. It reports:
+//
+//   - long options and option names that are not a single alphanumeric
+//     character (Guideline 3);
+//   - options taking more than one option-argument (Guideline 7);
+//   - Optional options, whose option-argument is optional (Guideline 7);
+//   - Kind accepting - or -- as an option, which prevents - from being an
+//     operand (Guideline 13) and -- from being the delimiter (Guideline 10).
+//
+// Only the Kind method of opts is called.
+func VetPOSIX(opts Options, names []string) []Issue {
+	var issues []Issue
+	report := func(name, msg string) {
+		issues = append(issues, Issue{name, msg})
+	}
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, "--"):
+			report(name, "long options are not specified by POSIX (Guideline 3)")
+		case len(name) != 2 || name[0] != '-' || !isAlnum(name[1]):
+			report(name, "option names should be a single alphanumeric character (Guideline 3)")
+		}
+		switch kind := opts.Kind(name); kind.Base() {
+		case Optional:
+			report(name, "option-arguments should not be optional (Guideline 7)")
+		case TakeTwoArgs:
+			if kind.NArgs() > 1 {
+				report(name, "options should take at most one option-argument (Guideline 7)")
+			}
+		case Rest, Terminated:
+			report(name, "options should take at most one option-argument (Guideline 7)")
+		}
+	}
+	if kind := opts.Kind("-"); kind != Unknown {
+		report("-", "Kind returns "+kind.String()+"; - should be an operand meaning the standard input (Guideline 13)")
+	}
+	if kind := opts.Kind("--"); kind != Unknown {
+		report("--", "Kind returns "+kind.String()+"; -- should be the delimiter of the options (Guideline 10)")
+	}
+	return issues
+}
+
+// VetPOSIX is like the package-level VetPOSIX for the options of s and its
+// subcommands. It also reports subcommands, which POSIX does not specify.
+func (s *Spec) VetPOSIX() []Issue {
+	var names []string
+	for _, o := range s.Options {
+		names = append(names, o.Names...)
+	}
+	issues := VetPOSIX(s, names)
+	for _, cmd := range s.Commands {
+		issues = append(issues, Issue{"", "subcommand " + cmd.Name + " is not specified by POSIX"})
+		issues = append(issues, cmd.VetPOSIX()...)
+	}
+	return issues
+}
+
+func isAlnum(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
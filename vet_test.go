@@ -0,0 +1,102 @@
+// Copyright (c) 2024 cions
+// Licensed under the MIT License. See LICENSE for details.
+
+package options
+
+import (
+	"strconv"
+	"testing"
+)
+
+type buggyOptions struct{}
+
+func (*buggyOptions) Kind(name string) Kind {
+	switch name {
+	case "-b", "--bool":
+		return Boolean
+	case "-s":
+		return TakeTwoArgs
+	case "--forgotten":
+		return Required
+	case "--weird":
+		return Kind(42)
+	case "--dash":
+		return Required | DashValue | NoDashValue
+	case "--input":
+		return Boolean | File
+	case "--missing":
+		return Unknown
+	default:
+		return Optional
+	}
+}
+
+func (*buggyOptions) Option(name, value string, hasValue bool) error {
+	switch name {
+	case "-b", "--bool":
+		_, err := strconv.ParseBool(value)
+		return err
+	case "--forgotten":
+		return ErrUnknown
+	}
+	return nil
+}
+
+func TestVet(t *testing.T) {
+	if issues := Vet(&TestOptions{}, []string{"-a", "-r", "-o", "-s", "--boolean", "--help"}); len(issues) != 0 {
+		t.Errorf("Vet(TestOptions): unexpected issues: %v", issues)
+	}
+
+	issues := Vet(&buggyOptions{}, []string{"-b", "-s", "--forgotten", "--weird", "--dash", "--input", "--missing", "-ab", "x"})
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	CompareSlice(t, "issues", got, []string{
+		`-b: Option fails without a value, but Kind returns Boolean: strconv.ParseBool: parsing "": invalid syntax`,
+		"-s: Kind returns TakeTwoArgs, but OptionN is not implemented",
+		"--forgotten: Kind accepts the option, but the handler returns ErrUnknown",
+		"--weird: Kind returns undefined Kind(42)",
+		"--dash: Kind returns both DashValue and NoDashValue",
+		"--input: Kind returns File or NewFile for an option without a value",
+		"--missing: Kind returns Unknown",
+		"-ab: not a valid option name",
+		"x: not a valid option name",
+		"--options-vet-probe: Kind returns Optional for an unknown option",
+	})
+}
+
+func TestVetPOSIX(t *testing.T) {
+	if issues := VetPOSIX(&TestOptions{}, []string{"-a", "-b", "-c", "-r"}); len(issues) != 0 {
+		t.Errorf("VetPOSIX(TestOptions): unexpected issues: %v", issues)
+	}
+
+	var got []string
+	for _, issue := range VetPOSIX(&buggyOptions{}, []string{"-b", "--bool", "-s", "-o", "-ab", "-?"}) {
+		got = append(got, issue.String())
+	}
+	CompareSlice(t, "issues", got, []string{
+		"--bool: long options are not specified by POSIX (Guideline 3)",
+		"-s: options should take at most one option-argument (Guideline 7)",
+		"-o: option-arguments should not be optional (Guideline 7)",
+		"-ab: option names should be a single alphanumeric character (Guideline 3)",
+		"-ab: option-arguments should not be optional (Guideline 7)",
+		"-?: option names should be a single alphanumeric character (Guideline 3)",
+		"-?: option-arguments should not be optional (Guideline 7)",
+		"-: Kind returns Optional; - should be an operand meaning the standard input (Guideline 13)",
+		"--: Kind returns Optional; -- should be the delimiter of the options (Guideline 10)",
+	})
+
+	got = nil
+	for _, issue := range newTestSpec().VetPOSIX() {
+		got = append(got, issue.String())
+	}
+	CompareSlice(t, "spec issues", got, []string{
+		"--verbose: long options are not specified by POSIX (Guideline 3)",
+		"--file: long options are not specified by POSIX (Guideline 3)",
+		"--color: long options are not specified by POSIX (Guideline 3)",
+		"--color: option-arguments should not be optional (Guideline 7)",
+		"subcommand run is not specified by POSIX",
+		"--dry-run: long options are not specified by POSIX (Guideline 3)",
+	})
+}